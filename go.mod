@@ -5,15 +5,18 @@ go 1.22.1
 require (
 	github.com/apparentlymart/go-tf-func-provider v0.0.0-20240303235123-0047ace1f889
 	github.com/google/go-cmp v0.6.0
+	github.com/hashicorp/hcl/v2 v2.20.0
 	github.com/zclconf/go-cty v1.14.3
 	github.com/zclconf/go-cty-debug v0.0.0-20240209213017-b8d9e32151be
 )
 
 require (
+	github.com/agext/levenshtein v1.2.1 // indirect
 	github.com/apparentlymart/go-ctxenv v1.0.0 // indirect
 	github.com/apparentlymart/go-shquot v0.0.1 // indirect
 	github.com/apparentlymart/go-textseg/v15 v15.0.0 // indirect
 	github.com/golang/protobuf v1.5.3 // indirect
+	github.com/mitchellh/go-wordwrap v0.0.0-20150314170334-ad45545899c7 // indirect
 	github.com/vmihailenco/msgpack/v5 v5.3.5 // indirect
 	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
 	go.rpcplugin.org/rpcplugin v0.3.1 // indirect
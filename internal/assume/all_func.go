@@ -0,0 +1,482 @@
+package assume
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+var allFunc = &function.Spec{
+	Description: "Assume that the given value will meet all of the assumptions in the given specification.",
+	Params: []function.Parameter{
+		{
+			Name:             "value",
+			Type:             cty.DynamicPseudoType,
+			Description:      "The value to make the assumption about.",
+			AllowNull:        true,
+			AllowUnknown:     true,
+			AllowDynamicType: true,
+		},
+		{
+			Name:        "assumptions",
+			Type:        cty.DynamicPseudoType,
+			Description: "An object describing the assumptions to make, using the attributes not_null, prefix, length, and number_range.",
+		},
+	},
+	Type: func(args []cty.Value) (cty.Type, error) {
+		return args[0].Type(), nil
+	},
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		v := args[0]
+		assumptions, err := decodeAssumptionSpec(args[1], v.Type())
+		if err != nil {
+			return cty.UnknownVal(retType), function.NewArgError(1, err)
+		}
+		ret, err := applyAssumptions(v, assumptions)
+		if err != nil {
+			return cty.UnknownVal(retType), function.NewArgError(0, err)
+		}
+		return ret, nil
+	},
+}
+
+var atFunc = &function.Spec{
+	Description: "Assume that a nested value at the given path will meet all of the assumptions in the given specification.",
+	Params: []function.Parameter{
+		{
+			Name:             "value",
+			Type:             cty.DynamicPseudoType,
+			Description:      "The value containing the nested value to make the assumption about.",
+			AllowNull:        true,
+			AllowUnknown:     true,
+			AllowDynamicType: true,
+		},
+		{
+			Name:        "path",
+			Type:        cty.DynamicPseudoType,
+			Description: "A list of attribute names, map keys, and element indices describing the path to the nested value.",
+		},
+		{
+			Name:        "assumptions",
+			Type:        cty.DynamicPseudoType,
+			Description: "An object describing the assumptions to make, using the attributes not_null, prefix, length, and number_range.",
+		},
+	},
+	Type: func(args []cty.Value) (cty.Type, error) {
+		return args[0].Type(), nil
+	},
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		v := args[0]
+		steps, leafTy, err := decodeAssumptionPath(args[1], v.Type())
+		if err != nil {
+			return cty.UnknownVal(retType), function.NewArgError(1, err)
+		}
+		if steps == nil {
+			// The path isn't fully known yet, so we can't make any
+			// assumptions yet.
+			return v, nil
+		}
+		assumptions, err := decodeAssumptionSpec(args[2], leafTy)
+		if err != nil {
+			return cty.UnknownVal(retType), function.NewArgError(2, err)
+		}
+		ret, err := applyAssumptionsAt(v, steps, nil, assumptions)
+		if err != nil {
+			return cty.UnknownVal(retType), function.NewArgError(0, err)
+		}
+		return ret, nil
+	},
+}
+
+// assumption is a single refinement decoded from an assumption
+// specification object, as used by the "all" function.
+type assumption struct {
+	// key is the attribute name from the specification object that this
+	// assumption was decoded from, for use in error messages.
+	key    string
+	refine func(b *cty.RefinementBuilder) *cty.RefinementBuilder
+
+	// explain returns an error describing why the given value doesn't
+	// conform to this assumption, or nil if there is no specific
+	// explanation.
+	explain func(v cty.Value) error
+}
+
+// assumptionSpecKeys are the attribute names that are valid in an assumption
+// specification object, in the order the assumptions are applied.
+var assumptionSpecKeys = []string{"not_null", "prefix", "length", "number_range"}
+
+// decodeAssumptionSpec decodes an assumption specification object, returning
+// the assumptions it describes in the order they should be applied.
+//
+// ty is the type of the value that the assumptions will apply to, which
+// is used to reject assumptions that cannot possibly apply to that type.
+// If ty is cty.DynamicPseudoType then all assumptions are accepted, but they
+// will have no effect until the value's type is known.
+//
+// The specification itself is always known, because its parameter doesn't
+// allow unknown values, but if any of its attributes are unknown then the
+// corresponding assumptions are skipped, because we can't know yet what
+// they will assume.
+func decodeAssumptionSpec(spec cty.Value, ty cty.Type) ([]assumption, error) {
+	specTy := spec.Type()
+	if !(specTy.IsObjectType() || specTy.IsMapType()) || spec.IsNull() {
+		return nil, fmt.Errorf("must be an object describing the assumptions to make")
+	}
+
+	attrs := spec.AsValueMap()
+	for name := range attrs {
+		if !isAssumptionSpecKey(name) {
+			return nil, fmt.Errorf("unsupported assumption %q", name)
+		}
+	}
+
+	var ret []assumption
+	for _, name := range assumptionSpecKeys {
+		raw, ok := attrs[name]
+		if !ok || raw.IsNull() {
+			continue
+		}
+		if !raw.IsWhollyKnown() {
+			continue
+		}
+		a, err := decodeAssumption(name, raw, ty)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %q assumption: %w", name, err)
+		}
+		if a.refine != nil {
+			ret = append(ret, a)
+		}
+	}
+	return ret, nil
+}
+
+func decodeAssumption(name string, raw cty.Value, ty cty.Type) (assumption, error) {
+	ret := assumption{key: name}
+	switch name {
+	case "not_null":
+		v, err := convert.Convert(raw, cty.Bool)
+		if err != nil {
+			return ret, fmt.Errorf("must be a boolean value")
+		}
+		if v.True() {
+			ret.refine = (*cty.RefinementBuilder).NotNull
+			ret.explain = explainNotNull
+		}
+	case "prefix":
+		if ty != cty.String && ty != cty.DynamicPseudoType {
+			return ret, fmt.Errorf("only strings can have a prefix, but value is %s", ty.FriendlyName())
+		}
+		v, err := convert.Convert(raw, cty.String)
+		if err != nil {
+			return ret, fmt.Errorf("must be a string")
+		}
+		prefix := v.AsString()
+		ret.refine = func(b *cty.RefinementBuilder) *cty.RefinementBuilder {
+			return b.StringPrefix(prefix)
+		}
+		ret.explain = func(v cty.Value) error {
+			return explainStringPrefix(v, prefix)
+		}
+	case "length":
+		if !(ty.IsListType() || ty.IsSetType() || ty.IsMapType() || ty == cty.DynamicPseudoType) {
+			return ret, fmt.Errorf("only lists, sets, and maps can have a length, but value is %s", ty.FriendlyName())
+		}
+		min, max, err := decodeAssumptionBounds(raw)
+		if err != nil {
+			return ret, err
+		}
+		minLen, maxLen := -1, -1
+		for _, bound := range []struct {
+			v   cty.Value
+			dst *int
+		}{{min, &minLen}, {max, &maxLen}} {
+			if bound.v.IsNull() {
+				continue
+			}
+			n, acc := bound.v.AsBigFloat().Int64()
+			if acc != big.Exact || n < 0 || n >= math.MaxInt {
+				return ret, fmt.Errorf("lengths must be whole numbers between 0 and %d", math.MaxInt)
+			}
+			*bound.dst = int(n)
+		}
+		ret.refine = func(b *cty.RefinementBuilder) *cty.RefinementBuilder {
+			if minLen >= 0 {
+				b = b.CollectionLengthLowerBound(minLen)
+			}
+			if maxLen >= 0 {
+				b = b.CollectionLengthUpperBound(maxLen)
+			}
+			return b
+		}
+		ret.explain = func(v cty.Value) error {
+			return explainCollectionLength(v, collectionNoun(v.Type()), minLen, maxLen)
+		}
+	case "number_range":
+		if ty != cty.Number && ty != cty.DynamicPseudoType {
+			return ret, fmt.Errorf("only numbers can have a number range, but value is %s", ty.FriendlyName())
+		}
+		min, max, err := decodeAssumptionBounds(raw)
+		if err != nil {
+			return ret, err
+		}
+		ret.refine = func(b *cty.RefinementBuilder) *cty.RefinementBuilder {
+			if !min.IsNull() {
+				b = b.NumberRangeLowerBound(min, true)
+			}
+			if !max.IsNull() {
+				b = b.NumberRangeUpperBound(max, true)
+			}
+			return b
+		}
+		ret.explain = func(v cty.Value) error {
+			lower, upper := min, max
+			if lower.IsNull() {
+				lower = cty.NilVal
+			}
+			if upper.IsNull() {
+				upper = cty.NilVal
+			}
+			return explainNumberRange(v, lower, upper, true)
+		}
+	}
+	return ret, nil
+}
+
+// decodeAssumptionBounds decodes a value that is either a single number,
+// representing equal lower and upper bounds, or a sequence of two numbers
+// representing the lower and upper bounds respectively.
+//
+// Either of the two bounds in the sequence form may be null to represent
+// that the range is unbounded in that direction.
+func decodeAssumptionBounds(raw cty.Value) (min, max cty.Value, err error) {
+	const msg = "must be either a number or a list of two numbers"
+	if raw.Type() == cty.Number {
+		return raw, raw, nil
+	}
+	list, convErr := convert.Convert(raw, cty.List(cty.Number))
+	if convErr != nil || list.LengthInt() != 2 {
+		return cty.NilVal, cty.NilVal, fmt.Errorf(msg)
+	}
+	min = list.Index(cty.Zero)
+	max = list.Index(cty.NumberIntVal(1))
+	if !min.IsNull() && !max.IsNull() && min.GreaterThan(max).True() {
+		return cty.NilVal, cty.NilVal, fmt.Errorf("lower bound must not be greater than upper bound")
+	}
+	return min, max, nil
+}
+
+func isAssumptionSpecKey(name string) bool {
+	for _, key := range assumptionSpecKeys {
+		if key == name {
+			return true
+		}
+	}
+	return false
+}
+
+// applyAssumptions applies all of the given assumptions to the given value
+// together, as a single chain of refinements.
+//
+// If the assumptions aren't upheld then the result is an error describing
+// the first assumption that can explain the failure, and the returned value
+// is invalid.
+func applyAssumptions(v cty.Value, assumptions []assumption) (cty.Value, error) {
+	if len(assumptions) == 0 {
+		return v, nil
+	}
+	refined, ok := tryApplyRefinement(v, func(b *cty.RefinementBuilder) *cty.RefinementBuilder {
+		for _, a := range assumptions {
+			b = a.refine(b)
+		}
+		return b
+	})
+	if ok {
+		return refined, nil
+	}
+	for _, a := range assumptions {
+		if err := a.explain(v); err != nil {
+			return cty.DynamicVal, fmt.Errorf("the %q assumption was not upheld: %w", a.key, err)
+		}
+	}
+	return cty.DynamicVal, errAssumptionNotUpheld
+}
+
+// collectionNoun returns a noun describing the kind of the given collection
+// type, for use in error messages.
+func collectionNoun(ty cty.Type) string {
+	switch {
+	case ty.IsListType():
+		return "list"
+	case ty.IsSetType():
+		return "set"
+	case ty.IsMapType():
+		return "map"
+	case ty.IsTupleType():
+		return "tuple"
+	default:
+		return "collection"
+	}
+}
+
+// decodeAssumptionPath decodes the path argument of the "at" function,
+// returning the individual steps and the type of the value that the path
+// refers to, given the type of the top-level value.
+//
+// The path itself is always known, because its parameter doesn't allow
+// unknown values, but if any of its steps are unknown then the result is nil
+// with no error.
+func decodeAssumptionPath(raw cty.Value, ty cty.Type) ([]cty.Value, cty.Type, error) {
+	rawTy := raw.Type()
+	if !(rawTy.IsListType() || rawTy.IsTupleType()) || raw.IsNull() {
+		return nil, cty.DynamicPseudoType, fmt.Errorf("must be a list of attribute names, map keys, and element indices")
+	}
+	if !raw.IsWhollyKnown() {
+		return nil, cty.DynamicPseudoType, nil
+	}
+	steps := make([]cty.Value, 0, raw.LengthInt())
+	for it := raw.ElementIterator(); it.Next(); {
+		_, step := it.Element()
+		if step.IsNull() || (step.Type() != cty.String && step.Type() != cty.Number) {
+			return nil, cty.DynamicPseudoType, fmt.Errorf("each path step must be either a string or a number")
+		}
+		steps = append(steps, step)
+	}
+	var path cty.Path
+	for _, step := range steps {
+		if ty == cty.DynamicPseudoType {
+			// We can't check the rest of the path until we know the
+			// value's type, so we'll check it again when traversing
+			// the value itself.
+			break
+		}
+		pathStep, elemTy, err := assumptionPathStep(ty, step)
+		if err != nil {
+			return nil, cty.DynamicPseudoType, fmt.Errorf("invalid path step after %q: %w", "value"+formatPath(path), err)
+		}
+		path = append(path, pathStep)
+		ty = elemTy
+	}
+	return steps, ty, nil
+}
+
+// assumptionPathStep interprets a single path step against a value of the
+// given type, returning the corresponding cty path step and the type of the
+// value that the step refers to.
+func assumptionPathStep(ty cty.Type, step cty.Value) (cty.PathStep, cty.Type, error) {
+	switch {
+	case ty.IsObjectType():
+		if step.Type() != cty.String {
+			return nil, cty.NilType, fmt.Errorf("an object attribute name must be a string")
+		}
+		name := step.AsString()
+		if !ty.HasAttribute(name) {
+			return nil, cty.NilType, fmt.Errorf("object has no attribute %q", name)
+		}
+		return cty.GetAttrStep{Name: name}, ty.AttributeType(name), nil
+	case ty.IsMapType():
+		if step.Type() != cty.String {
+			return nil, cty.NilType, fmt.Errorf("a map key must be a string")
+		}
+		return cty.IndexStep{Key: step}, ty.ElementType(), nil
+	case ty.IsListType() || ty.IsTupleType():
+		if step.Type() != cty.Number {
+			return nil, cty.NilType, fmt.Errorf("an element index must be a number")
+		}
+		idx, acc := step.AsBigFloat().Int64()
+		if acc != big.Exact || idx < 0 {
+			return nil, cty.NilType, fmt.Errorf("an element index must be a whole number greater than or equal to zero")
+		}
+		if ty.IsListType() {
+			return cty.IndexStep{Key: step}, ty.ElementType(), nil
+		}
+		etys := ty.TupleElementTypes()
+		if idx >= int64(len(etys)) {
+			return nil, cty.NilType, fmt.Errorf("tuple has only %d elements", len(etys))
+		}
+		return cty.IndexStep{Key: step}, etys[idx], nil
+	default:
+		return nil, cty.NilType, fmt.Errorf("cannot refer to a nested value in %s", ty.FriendlyName())
+	}
+}
+
+// applyAssumptionsAt applies the given assumptions to the value nested inside
+// v at the given path steps, returning a new value with the same structure as
+// v but with the nested value replaced by its refined equivalent.
+//
+// If any of the values along the path are unknown then they are returned
+// unchanged, because we can't refine the contents of an unknown value.
+//
+// The path argument is the path from the top-level value to v, for use in
+// error messages.
+func applyAssumptionsAt(v cty.Value, steps []cty.Value, path cty.Path, assumptions []assumption) (cty.Value, error) {
+	if len(steps) == 0 {
+		ret, err := applyAssumptions(v, assumptions)
+		if err != nil {
+			if len(path) == 0 {
+				return cty.NilVal, err
+			}
+			return cty.NilVal, fmt.Errorf("%s: %w", formatPath(path), err)
+		}
+		return ret, nil
+	}
+	if !v.IsKnown() {
+		return v, nil
+	}
+	if v.IsNull() {
+		if len(path) == 0 {
+			return cty.NilVal, fmt.Errorf("value is null")
+		}
+		return cty.NilVal, fmt.Errorf("%s is null", formatPath(path))
+	}
+
+	ty := v.Type()
+	pathStep, _, err := assumptionPathStep(ty, steps[0])
+	if err != nil {
+		return cty.NilVal, fmt.Errorf("invalid path step after %q: %w", "value"+formatPath(path), err)
+	}
+	childPath := append(path.Copy(), pathStep)
+	switch {
+	case ty.IsObjectType():
+		name := pathStep.(cty.GetAttrStep).Name
+		attrs := v.AsValueMap()
+		child, err := applyAssumptionsAt(attrs[name], steps[1:], childPath, assumptions)
+		if err != nil {
+			return cty.NilVal, err
+		}
+		attrs[name] = child
+		return cty.ObjectVal(attrs), nil
+	case ty.IsMapType():
+		key := steps[0].AsString()
+		elems := v.AsValueMap()
+		elem, exists := elems[key]
+		if !exists {
+			return cty.NilVal, fmt.Errorf("%s does not exist", formatPath(childPath))
+		}
+		child, err := applyAssumptionsAt(elem, steps[1:], childPath, assumptions)
+		if err != nil {
+			return cty.NilVal, err
+		}
+		elems[key] = child
+		return cty.MapVal(elems), nil
+	default: // list or tuple, because assumptionPathStep accepted it
+		idx, _ := steps[0].AsBigFloat().Int64()
+		elems := v.AsValueSlice()
+		if idx >= int64(len(elems)) {
+			return cty.NilVal, fmt.Errorf("%s does not exist", formatPath(childPath))
+		}
+		child, err := applyAssumptionsAt(elems[idx], steps[1:], childPath, assumptions)
+		if err != nil {
+			return cty.NilVal, err
+		}
+		elems[idx] = child
+		if ty.IsTupleType() {
+			return cty.TupleVal(elems), nil
+		}
+		return cty.ListVal(elems), nil
+	}
+}
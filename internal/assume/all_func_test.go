@@ -0,0 +1,365 @@
+package assume
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestAllFunc(t *testing.T) {
+	testProviderFuncs(t, funcTests{
+		"all": {
+			"dynamicval": {
+				Args: []cty.Value{
+					cty.DynamicVal,
+					cty.ObjectVal(map[string]cty.Value{
+						"not_null": cty.True,
+						"prefix":   cty.StringVal("arn:"),
+					}),
+				},
+				Want: cty.DynamicVal,
+			},
+			"unknown string": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.ObjectVal(map[string]cty.Value{
+						"not_null": cty.True,
+						"prefix":   cty.StringVal("arn:"),
+					}),
+				},
+				Want: cty.UnknownVal(cty.String).Refine().
+					NotNull().
+					StringPrefix("arn:").
+					NewValue(),
+			},
+			"unknown list": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.List(cty.String)),
+					cty.ObjectVal(map[string]cty.Value{
+						"length": cty.TupleVal([]cty.Value{cty.NumberIntVal(1), cty.NumberIntVal(3)}),
+					}),
+				},
+				Want: cty.UnknownVal(cty.List(cty.String)).Refine().
+					CollectionLengthLowerBound(1).
+					CollectionLengthUpperBound(3).
+					NewValue(),
+			},
+			"unknown list with exact length": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.List(cty.String)),
+					cty.ObjectVal(map[string]cty.Value{
+						"not_null": cty.True,
+						"length":   cty.NumberIntVal(2),
+					}),
+				},
+				Want: cty.ListVal([]cty.Value{cty.UnknownVal(cty.String), cty.UnknownVal(cty.String)}),
+			},
+			"unknown number with open range": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.Number),
+					cty.ObjectVal(map[string]cty.Value{
+						"number_range": cty.TupleVal([]cty.Value{cty.Zero, cty.NullVal(cty.Number)}),
+					}),
+				},
+				Want: cty.UnknownVal(cty.Number).Refine().
+					NumberRangeLowerBound(cty.Zero, true).
+					NewValue(),
+			},
+			"unknown spec attribute": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.ObjectVal(map[string]cty.Value{
+						"not_null": cty.True,
+						"prefix":   cty.UnknownVal(cty.String),
+					}),
+				},
+				Want: cty.UnknownVal(cty.String).RefineNotNull(),
+			},
+			"known value with unknown spec attribute": {
+				Args: []cty.Value{
+					cty.StringVal("foo"),
+					cty.ObjectVal(map[string]cty.Value{
+						"prefix": cty.UnknownVal(cty.String),
+					}),
+				},
+				Want: cty.StringVal("foo"),
+			},
+			"unknown spec": {
+				// The function isn't called at all for an unknown spec, so
+				// the result is a wholly-unknown value.
+				Args: []cty.Value{
+					cty.StringVal("foo"),
+					cty.UnknownVal(cty.Object(map[string]cty.Type{"prefix": cty.String})),
+				},
+				Want: cty.UnknownVal(cty.String),
+			},
+			"known number in range": {
+				Args: []cty.Value{
+					cty.NumberIntVal(5),
+					cty.ObjectVal(map[string]cty.Value{
+						"not_null":     cty.True,
+						"number_range": cty.TupleVal([]cty.Value{cty.Zero, cty.NumberIntVal(10)}),
+					}),
+				},
+				Want: cty.NumberIntVal(5),
+			},
+			"known number out of range": {
+				Args: []cty.Value{
+					cty.NumberIntVal(11),
+					cty.ObjectVal(map[string]cty.Value{
+						"not_null":     cty.True,
+						"number_range": cty.TupleVal([]cty.Value{cty.Zero, cty.NumberIntVal(10)}),
+					}),
+				},
+				WantErr: "the \"number_range\" assumption was not upheld: 11 is greater than assumed maximum 10",
+			},
+			"null string": {
+				Args: []cty.Value{
+					cty.NullVal(cty.String),
+					cty.ObjectVal(map[string]cty.Value{
+						"not_null": cty.True,
+						"prefix":   cty.StringVal("arn:"),
+					}),
+				},
+				WantErr: "the \"not_null\" assumption was not upheld: value is null but was assumed to never be null",
+			},
+			"known string with wrong prefix": {
+				Args: []cty.Value{
+					cty.StringVal("foo"),
+					cty.ObjectVal(map[string]cty.Value{
+						"not_null": cty.True,
+						"prefix":   cty.StringVal("arn:"),
+					}),
+				},
+				WantErr: "the \"prefix\" assumption was not upheld: value \"foo\" does not start with assumed prefix \"arn:\"",
+			},
+			"unknown string with conflicting prefix": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String).Refine().StringPrefixFull("arn:aws-cn:").NewValue(),
+					cty.ObjectVal(map[string]cty.Value{
+						"not_null": cty.True,
+						"prefix":   cty.StringVal("arn:aws:"),
+					}),
+				},
+				WantErr: "the \"prefix\" assumption was not upheld: value already known to start with \"arn:aws-cn:\" which conflicts with assumed prefix \"arn:aws:\"",
+			},
+			"unsupported assumption": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.ObjectVal(map[string]cty.Value{
+						"suffix": cty.StringVal("x"),
+					}),
+				},
+				WantErr: `unsupported assumption "suffix"`,
+			},
+			"assumption not applicable to type": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.List(cty.String)),
+					cty.ObjectVal(map[string]cty.Value{
+						"prefix": cty.StringVal("x"),
+					}),
+				},
+				WantErr: `invalid "prefix" assumption: only strings can have a prefix, but value is list of string`,
+			},
+			"invalid bounds": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.List(cty.String)),
+					cty.ObjectVal(map[string]cty.Value{
+						"length": cty.TupleVal([]cty.Value{cty.NumberIntVal(1)}),
+					}),
+				},
+				WantErr: `invalid "length" assumption: must be either a number or a list of two numbers`,
+			},
+			"inverted bounds": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.Number),
+					cty.ObjectVal(map[string]cty.Value{
+						"number_range": cty.TupleVal([]cty.Value{cty.NumberIntVal(2), cty.NumberIntVal(1)}),
+					}),
+				},
+				WantErr: `invalid "number_range" assumption: lower bound must not be greater than upper bound`,
+			},
+			"fractional length": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.Map(cty.String)),
+					cty.ObjectVal(map[string]cty.Value{
+						"length": cty.NumberFloatVal(1.5),
+					}),
+				},
+				WantErr: fmt.Sprintf(`invalid "length" assumption: lengths must be whole numbers between 0 and %d`, math.MaxInt),
+			},
+			"spec not an object": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.StringVal("not_null"),
+				},
+				WantErr: `must be an object describing the assumptions to make`,
+			},
+		},
+		"at": {
+			"nested attribute in known object": {
+				Args: []cty.Value{
+					cty.ObjectVal(map[string]cty.Value{
+						"name": cty.StringVal("a"),
+						"network": cty.ObjectVal(map[string]cty.Value{
+							"subnet_id": cty.UnknownVal(cty.String),
+						}),
+					}),
+					cty.TupleVal([]cty.Value{cty.StringVal("network"), cty.StringVal("subnet_id")}),
+					cty.ObjectVal(map[string]cty.Value{
+						"not_null": cty.True,
+						"prefix":   cty.StringVal("subnet-"),
+					}),
+				},
+				Want: cty.ObjectVal(map[string]cty.Value{
+					"name": cty.StringVal("a"),
+					"network": cty.ObjectVal(map[string]cty.Value{
+						"subnet_id": cty.UnknownVal(cty.String).Refine().
+							NotNull().
+							StringPrefix("subnet-").
+							NewValue(),
+					}),
+				}),
+			},
+			"element in known list and map": {
+				Args: []cty.Value{
+					cty.ListVal([]cty.Value{
+						cty.MapValEmpty(cty.List(cty.String)),
+						cty.MapVal(map[string]cty.Value{
+							"a": cty.UnknownVal(cty.List(cty.String)),
+						}),
+					}),
+					cty.TupleVal([]cty.Value{cty.NumberIntVal(1), cty.StringVal("a")}),
+					cty.ObjectVal(map[string]cty.Value{
+						"length": cty.TupleVal([]cty.Value{cty.NumberIntVal(1), cty.NullVal(cty.Number)}),
+					}),
+				},
+				Want: cty.ListVal([]cty.Value{
+					cty.MapValEmpty(cty.List(cty.String)),
+					cty.MapVal(map[string]cty.Value{
+						"a": cty.UnknownVal(cty.List(cty.String)).Refine().
+							CollectionLengthLowerBound(1).
+							NewValue(),
+					}),
+				}),
+			},
+			"element in known tuple": {
+				Args: []cty.Value{
+					cty.TupleVal([]cty.Value{cty.StringVal("a"), cty.UnknownVal(cty.Number)}),
+					cty.TupleVal([]cty.Value{cty.NumberIntVal(1)}),
+					cty.ObjectVal(map[string]cty.Value{
+						"number_range": cty.TupleVal([]cty.Value{cty.Zero, cty.NumberIntVal(10)}),
+					}),
+				},
+				Want: cty.TupleVal([]cty.Value{
+					cty.StringVal("a"),
+					cty.UnknownVal(cty.Number).Refine().
+						NumberRangeInclusive(cty.Zero, cty.NumberIntVal(10)).
+						NewValue(),
+				}),
+			},
+			"unknown container": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.Object(map[string]cty.Type{"id": cty.String})),
+					cty.TupleVal([]cty.Value{cty.StringVal("id")}),
+					cty.ObjectVal(map[string]cty.Value{
+						"not_null": cty.True,
+					}),
+				},
+				Want: cty.UnknownVal(cty.Object(map[string]cty.Type{"id": cty.String})),
+			},
+			"unknown path step": {
+				// We can't know which nested value to refine yet, so the
+				// value is returned unchanged.
+				Args: []cty.Value{
+					cty.MapVal(map[string]cty.Value{"a": cty.UnknownVal(cty.String)}),
+					cty.TupleVal([]cty.Value{cty.UnknownVal(cty.String)}),
+					cty.ObjectVal(map[string]cty.Value{
+						"not_null": cty.True,
+					}),
+				},
+				Want: cty.MapVal(map[string]cty.Value{"a": cty.UnknownVal(cty.String)}),
+			},
+			"unknown path": {
+				// The function isn't called at all for an unknown path, so
+				// the result is a wholly-unknown value.
+				Args: []cty.Value{
+					cty.ObjectVal(map[string]cty.Value{"id": cty.StringVal("a")}),
+					cty.UnknownVal(cty.List(cty.String)),
+					cty.ObjectVal(map[string]cty.Value{
+						"not_null": cty.True,
+					}),
+				},
+				Want: cty.UnknownVal(cty.Object(map[string]cty.Type{"id": cty.String})),
+			},
+			"empty path": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.EmptyTupleVal,
+					cty.ObjectVal(map[string]cty.Value{
+						"not_null": cty.True,
+					}),
+				},
+				Want: cty.UnknownVal(cty.String).RefineNotNull(),
+			},
+			"known leaf that fails": {
+				Args: []cty.Value{
+					cty.ObjectVal(map[string]cty.Value{
+						"network": cty.ObjectVal(map[string]cty.Value{
+							"subnet_ids": cty.ListVal([]cty.Value{cty.StringVal("vpc-1")}),
+						}),
+					}),
+					cty.TupleVal([]cty.Value{cty.StringVal("network"), cty.StringVal("subnet_ids"), cty.NumberIntVal(0)}),
+					cty.ObjectVal(map[string]cty.Value{
+						"prefix": cty.StringVal("subnet-"),
+					}),
+				},
+				WantErr: ".network.subnet_ids[0]: the \"prefix\" assumption was not upheld: value \"vpc-1\" does not start with assumed prefix \"subnet-\"",
+			},
+			"null container": {
+				Args: []cty.Value{
+					cty.ObjectVal(map[string]cty.Value{
+						"network": cty.NullVal(cty.Object(map[string]cty.Type{"subnet_id": cty.String})),
+					}),
+					cty.TupleVal([]cty.Value{cty.StringVal("network"), cty.StringVal("subnet_id")}),
+					cty.ObjectVal(map[string]cty.Value{
+						"not_null": cty.True,
+					}),
+				},
+				WantErr: `.network is null`,
+			},
+			"missing map key": {
+				Args: []cty.Value{
+					cty.MapVal(map[string]cty.Value{"a": cty.StringVal("x")}),
+					cty.TupleVal([]cty.Value{cty.StringVal("b")}),
+					cty.ObjectVal(map[string]cty.Value{
+						"not_null": cty.True,
+					}),
+				},
+				WantErr: `["b"] does not exist`,
+			},
+			"invalid attribute name": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.Object(map[string]cty.Type{"id": cty.String})),
+					cty.TupleVal([]cty.Value{cty.StringVal("name")}),
+					cty.ObjectVal(map[string]cty.Value{
+						"not_null": cty.True,
+					}),
+				},
+				WantErr: `invalid path step after "value": object has no attribute "name"`,
+			},
+			"assumption not applicable to leaf type": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.Object(map[string]cty.Type{"id": cty.Number})),
+					cty.TupleVal([]cty.Value{cty.StringVal("id")}),
+					cty.ObjectVal(map[string]cty.Value{
+						"prefix": cty.StringVal("x"),
+					}),
+				},
+				WantErr: `invalid "prefix" assumption: only strings can have a prefix, but value is number`,
+			},
+		},
+	})
+}
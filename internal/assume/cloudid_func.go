@@ -0,0 +1,597 @@
+package assume
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// arnComponentNames are the names of the components accepted by awsarn, in
+// the order they appear in an ARN.
+var arnComponentNames = []string{
+	"partition",
+	"service",
+	"region",
+	"account",
+	"resource_type",
+	"resource",
+}
+
+var awsarnFunc = &function.Spec{
+	Description: "Assume that the given string will be an AWS ARN with the given components.",
+	Params: []function.Parameter{
+		{
+			Name:         "value",
+			Type:         cty.String,
+			Description:  "The value to make the assumption about.",
+			AllowNull:    true,
+			AllowUnknown: true,
+		},
+		{
+			Name:             "components",
+			Type:             cty.DynamicPseudoType,
+			Description:      "An object describing some or all of partition, service, region, account, resource_type, and resource.",
+			AllowUnknown:     true,
+			AllowDynamicType: true,
+		},
+	},
+	Type: function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		v := args[0]
+		comps, err := stringComponents(args[1], arnComponentNames)
+		if err != nil {
+			return cty.UnknownVal(retType), function.NewArgError(1, err)
+		}
+		if err := checkARNComponents(comps); err != nil {
+			return cty.UnknownVal(retType), function.NewArgError(1, err)
+		}
+
+		if v.IsKnown() {
+			if v.IsNull() {
+				return cty.UnknownVal(retType), function.NewArgErrorf(0, "value is null but was assumed to be an ARN")
+			}
+			actual, ok := parseARN(v.AsString())
+			if !ok {
+				return cty.UnknownVal(retType), function.NewArgErrorf(0, "value %s is not an ARN, which must have the form arn:PARTITION:SERVICE:REGION:ACCOUNT:RESOURCE", displayActualValue(v))
+			}
+			if diffs := arnDiffs(actual, comps); len(diffs) != 0 {
+				return cty.UnknownVal(retType), function.NewArgErrorf(0, "value %s does not match the assumed ARN:\n  %s", displayActualValue(v), strings.Join(diffs, "\n  "))
+			}
+			return v, nil
+		}
+
+		prefix, complete := renderARN(comps)
+		if complete {
+			rendered := cty.StringVal(prefix)
+			if eq := v.Equals(rendered); eq.IsKnown() && eq.False() {
+				return cty.UnknownVal(retType), function.NewArgErrorf(0, "value %s does not match the assumed ARN %q", displayActualValue(v), prefix)
+			}
+			return rendered, nil
+		}
+		return refineStringPrefix(v, prefix, true, "implied by the assumed ARN components")
+	},
+}
+
+// checkARNComponents returns an error if any of the known ARN components
+// could not possibly appear in an ARN.
+func checkARNComponents(comps map[string]cty.Value) error {
+	for _, name := range arnComponentNames[:4] {
+		if val, ok := comps[name]; ok && val.IsKnown() && strings.Contains(val.AsString(), ":") {
+			return fmt.Errorf("component %q must not contain colons", name)
+		}
+	}
+	if val, ok := comps["resource_type"]; ok && val.IsKnown() {
+		s := val.AsString()
+		if i := strings.IndexAny(s, "/:"); i < 0 || i != len(s)-1 {
+			return fmt.Errorf(`component "resource_type" must be a resource type followed by its separator, like "role/" or "function:"`)
+		}
+	}
+	return nil
+}
+
+// parseARN splits the given string into the components accepted by awsarn,
+// or returns false if it is not an ARN.
+//
+// The resource type is everything in the resource part up to and including
+// the first slash or colon, or an empty string if there is neither. The
+// returned map also includes the entire resource part under the key
+// "resource_part".
+func parseARN(s string) (map[string]string, bool) {
+	parts := strings.SplitN(s, ":", 6)
+	if len(parts) != 6 || parts[0] != "arn" {
+		return nil, false
+	}
+	ret := map[string]string{
+		"partition":     parts[1],
+		"service":       parts[2],
+		"region":        parts[3],
+		"account":       parts[4],
+		"resource_part": parts[5],
+		"resource":      parts[5],
+	}
+	if i := strings.IndexAny(parts[5], "/:"); i >= 0 {
+		ret["resource_type"] = parts[5][:i+1]
+		ret["resource"] = parts[5][i+1:]
+	}
+	return ret, true
+}
+
+// arnDiffs returns descriptions of each of the ways that the given parsed
+// ARN differs from the assumed components.
+//
+// If resource_type is omitted then the resource component is compared with
+// the entire resource part of the ARN, since not all ARNs have a resource
+// type.
+//
+// An unknown account component requires a 12-digit account id, but an
+// omitted account component allows any account, because some ARNs have
+// something else in that position, such as "aws" for AWS-managed IAM
+// policies.
+func arnDiffs(actual map[string]string, comps map[string]cty.Value) []string {
+	var diffs []string
+	_, hasType := comps["resource_type"]
+	for _, name := range arnComponentNames {
+		want, ok := comps[name]
+		if !ok {
+			continue
+		}
+		got, present := actual[name]
+		if name == "resource" && !hasType {
+			got = actual["resource_part"]
+		}
+		if !want.IsKnown() {
+			switch {
+			case name == "account" && !isAWSAccountID(got):
+				diffs = append(diffs, fmt.Sprintf("account is %s but was assumed to be a 12-digit account id", displayActualString(got)))
+			case name == "resource_type" && !present:
+				diffs = append(diffs, "resource_type is missing but was assumed to be present")
+			}
+			continue
+		}
+		if !present {
+			diffs = append(diffs, fmt.Sprintf("%s is missing but was assumed to be %q", name, want.AsString()))
+			continue
+		}
+		if got != want.AsString() {
+			diffs = append(diffs, fmt.Sprintf("%s is %s but was assumed to be %q", name, displayActualString(got), want.AsString()))
+		}
+	}
+	return diffs
+}
+
+// renderARN returns the longest prefix of an ARN that the given components
+// imply, and whether that prefix is the entire ARN.
+func renderARN(comps map[string]cty.Value) (string, bool) {
+	var buf strings.Builder
+	buf.WriteString("arn:")
+	for _, name := range arnComponentNames {
+		val, ok := comps[name]
+		if name == "resource_type" && !ok {
+			// The resource component then describes the entire resource
+			// part of the ARN.
+			continue
+		}
+		if !ok || !val.IsKnown() {
+			return buf.String(), false
+		}
+		buf.WriteString(val.AsString())
+		if name != "resource_type" && name != "resource" {
+			buf.WriteByte(':')
+		}
+	}
+	return buf.String(), true
+}
+
+// isAWSAccountID returns true if the given string is a 12-digit AWS account
+// id.
+func isAWSAccountID(s string) bool {
+	if len(s) != 12 {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// azureComponentNames are the names of the components accepted by
+// azureresourceid, in the order they appear in a resource id.
+var azureComponentNames = []string{
+	"subscription",
+	"resource_group",
+	"provider",
+	"resource_type",
+	"name",
+}
+
+// azureSegmentKeys are the fixed path segments that precede each of the
+// components of an Azure resource id, other than the name which directly
+// follows the resource type.
+var azureSegmentKeys = map[string]string{
+	"subscription":   "subscriptions",
+	"resource_group": "resourceGroups",
+	"provider":       "providers",
+}
+
+var azureresourceidFunc = &function.Spec{
+	Description: "Assume that the given string will be an Azure resource id with the given components.",
+	Params: []function.Parameter{
+		{
+			Name:         "value",
+			Type:         cty.String,
+			Description:  "The value to make the assumption about.",
+			AllowNull:    true,
+			AllowUnknown: true,
+		},
+		{
+			Name:             "components",
+			Type:             cty.DynamicPseudoType,
+			Description:      "An object describing some or all of subscription, resource_group, provider, resource_type, and name.",
+			AllowUnknown:     true,
+			AllowDynamicType: true,
+		},
+	},
+	Type: function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		v := args[0]
+		comps, err := stringComponents(args[1], azureComponentNames)
+		if err != nil {
+			return cty.UnknownVal(retType), function.NewArgError(1, err)
+		}
+		if err := checkPathComponents(comps, azureComponentNames[:4]); err != nil {
+			return cty.UnknownVal(retType), function.NewArgError(1, err)
+		}
+
+		rendered, complete := renderAzureResourceID(comps)
+		if v.IsKnown() {
+			if v.IsNull() {
+				return cty.UnknownVal(retType), function.NewArgErrorf(0, "value is null but was assumed to be an Azure resource id")
+			}
+			actual, ok := parseAzureResourceID(v.AsString())
+			if !ok {
+				return cty.UnknownVal(retType), function.NewArgErrorf(0, "value %s is not an Azure resource id, which must have the form /subscriptions/SUBSCRIPTION/resourceGroups/RESOURCE_GROUP/providers/PROVIDER/RESOURCE_TYPE/NAME", displayActualValue(v))
+			}
+			if diffs := azureResourceIDDiffs(actual, comps); len(diffs) != 0 {
+				return cty.UnknownVal(retType), function.NewArgErrorf(0, "value %s does not match the assumed Azure resource id:\n  %s", displayActualValue(v), strings.Join(diffs, "\n  "))
+			}
+			if complete {
+				// Azure treats resource ids case-insensitively and doesn't
+				// always preserve the case it was given, so we return the
+				// id as written in the components to make sure the result
+				// is the same as we'd have returned during planning.
+				return cty.StringVal(rendered), nil
+			}
+			// The id might be written differently than the prefix we'd
+			// have promised during planning, such as with the fixed
+			// segment names in a different case.
+			if prefix := azureResourceIDPrefix(rendered); !strings.HasPrefix(v.AsString(), prefix) {
+				return cty.UnknownVal(retType), function.NewArgErrorf(0, "value %s does not start with %q, implied by the assumed Azure resource id components", displayActualValue(v), prefix)
+			}
+			return v, nil
+		}
+
+		if complete {
+			if have := v.Range().StringPrefix(); !hasPrefixFold(rendered, have) {
+				return cty.UnknownVal(retType), function.NewArgErrorf(0, "value %s does not match the assumed Azure resource id %q", displayActualValue(v), rendered)
+			}
+			return cty.StringVal(rendered), nil
+		}
+		prefix := azureResourceIDPrefix(rendered)
+		return refineStringPrefix(v, prefix, true, "implied by the assumed Azure resource id components")
+	},
+}
+
+// parseAzureResourceID splits the given string into the components accepted
+// by azureresourceid, or returns false if it is not an Azure resource id.
+//
+// The fixed segment names are matched case-insensitively. The id of a
+// resource group has no provider, resource type, or name, and so those are
+// omitted from the result. The name includes everything after the resource
+// type, including the types and names of any child resources.
+func parseAzureResourceID(s string) (map[string]string, bool) {
+	segs := strings.Split(s, "/")
+	if len(segs) < 5 || segs[0] != "" || !strings.EqualFold(segs[1], "subscriptions") || !strings.EqualFold(segs[3], "resourceGroups") {
+		return nil, false
+	}
+	ret := map[string]string{
+		"subscription":   segs[2],
+		"resource_group": segs[4],
+	}
+	if len(segs) == 5 {
+		return ret, true
+	}
+	if len(segs) < 9 || !strings.EqualFold(segs[5], "providers") {
+		return nil, false
+	}
+	ret["provider"] = segs[6]
+	ret["resource_type"] = segs[7]
+	ret["name"] = strings.Join(segs[8:], "/")
+	return ret, true
+}
+
+// azureResourceIDDiffs returns descriptions of each of the ways that the
+// given parsed Azure resource id differs from the assumed components.
+//
+// Azure resource ids are case-insensitive, so the components are compared
+// case-insensitively.
+func azureResourceIDDiffs(actual map[string]string, comps map[string]cty.Value) []string {
+	var diffs []string
+	for _, name := range azureComponentNames {
+		want, ok := comps[name]
+		if !ok {
+			continue
+		}
+		got, present := actual[name]
+		if !want.IsKnown() {
+			switch {
+			case !present:
+				diffs = append(diffs, fmt.Sprintf("%s is missing but was assumed to be present", name))
+			case name == "subscription" && !isUUID(got):
+				diffs = append(diffs, fmt.Sprintf("subscription is %s but was assumed to be a subscription id", displayActualString(got)))
+			}
+			continue
+		}
+		if !present {
+			diffs = append(diffs, fmt.Sprintf("%s is missing but was assumed to be %q", name, want.AsString()))
+			continue
+		}
+		if !strings.EqualFold(got, want.AsString()) {
+			diffs = append(diffs, fmt.Sprintf("%s is %s but was assumed to be %q", name, displayActualString(got), want.AsString()))
+		}
+	}
+	return diffs
+}
+
+// renderAzureResourceID returns the longest prefix of an Azure resource id
+// that the given components imply, and whether that prefix is the entire id.
+func renderAzureResourceID(comps map[string]cty.Value) (string, bool) {
+	var buf strings.Builder
+	buf.WriteByte('/')
+	for _, name := range azureComponentNames {
+		if key, ok := azureSegmentKeys[name]; ok {
+			buf.WriteString(key)
+			buf.WriteByte('/')
+		}
+		val, ok := comps[name]
+		if !ok || !val.IsKnown() {
+			return buf.String(), false
+		}
+		buf.WriteString(val.AsString())
+		if name != "name" {
+			buf.WriteByte('/')
+		}
+	}
+	return buf.String(), true
+}
+
+// azureResourceIDPrefix returns the part of the given rendered prefix of an
+// Azure resource id that we can promise the final id will start with.
+//
+// Azure doesn't always preserve the case of the segments in an id, so the
+// result is the leading "/subscriptions/", which Azure always writes in
+// lowercase, followed by as much of the rest as has no letters.
+func azureResourceIDPrefix(rendered string) string {
+	const fixed = "/subscriptions/"
+	rest := rendered[len(fixed):]
+	if i := strings.IndexFunc(rest, unicode.IsLetter); i >= 0 {
+		rest = rest[:i]
+	}
+	return fixed + rest
+}
+
+// gcpComponentNames are the names of the components accepted by gcpselflink,
+// in the order they appear in a self link.
+var gcpComponentNames = []string{
+	"service",
+	"version",
+	"project",
+	"location",
+	"resource_type",
+	"name",
+}
+
+// gcpSelfLinkBase is the part of the URL that all self links start with.
+const gcpSelfLinkBase = "https://www.googleapis.com/"
+
+// gcpZonePattern matches Google Cloud zone names, like "us-central1-a",
+// distinguishing them from region names like "us-central1".
+var gcpZonePattern = regexp.MustCompile(`^[a-z]+-[a-z]+[0-9]+-[a-z]$`)
+
+var gcpselflinkFunc = &function.Spec{
+	Description: "Assume that the given string will be a Google Cloud self link with the given components.",
+	Params: []function.Parameter{
+		{
+			Name:         "value",
+			Type:         cty.String,
+			Description:  "The value to make the assumption about.",
+			AllowNull:    true,
+			AllowUnknown: true,
+		},
+		{
+			Name:             "components",
+			Type:             cty.DynamicPseudoType,
+			Description:      "An object describing some or all of service, version, project, location, resource_type, and name.",
+			AllowUnknown:     true,
+			AllowDynamicType: true,
+		},
+	},
+	Type: function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		v := args[0]
+		comps, err := stringComponents(args[1], gcpComponentNames)
+		if err != nil {
+			return cty.UnknownVal(retType), function.NewArgError(1, err)
+		}
+		if err := checkPathComponents(comps, gcpComponentNames[:5]); err != nil {
+			return cty.UnknownVal(retType), function.NewArgError(1, err)
+		}
+
+		prefix, complete := renderGCPSelfLink(comps)
+		if v.IsKnown() {
+			if v.IsNull() {
+				return cty.UnknownVal(retType), function.NewArgErrorf(0, "value is null but was assumed to be a self link")
+			}
+			actual, ok := parseGCPSelfLink(v.AsString())
+			if !ok {
+				return cty.UnknownVal(retType), function.NewArgErrorf(0, "value %s is not a self link, which must have the form %sSERVICE/VERSION/projects/PROJECT/LOCATION/RESOURCE_TYPE/NAME", displayActualValue(v), gcpSelfLinkBase)
+			}
+			if diffs := gcpSelfLinkDiffs(actual, comps); len(diffs) != 0 {
+				return cty.UnknownVal(retType), function.NewArgErrorf(0, "value %s does not match the assumed self link:\n  %s", displayActualValue(v), strings.Join(diffs, "\n  "))
+			}
+			// The components might all match while the self link still
+			// differs from what we'd have promised during planning, such
+			// as if it names a zone under "regions/".
+			if !strings.HasPrefix(v.AsString(), prefix) {
+				return cty.UnknownVal(retType), function.NewArgErrorf(0, "value %s does not start with %q, implied by the assumed self link components", displayActualValue(v), prefix)
+			}
+			return v, nil
+		}
+
+		if complete {
+			rendered := cty.StringVal(prefix)
+			if eq := v.Equals(rendered); eq.IsKnown() && eq.False() {
+				return cty.UnknownVal(retType), function.NewArgErrorf(0, "value %s does not match the assumed self link %q", displayActualValue(v), prefix)
+			}
+			return rendered, nil
+		}
+		return refineStringPrefix(v, prefix, true, "implied by the assumed self link components")
+	},
+}
+
+// parseGCPSelfLink splits the given string into the components accepted by
+// gcpselflink, or returns false if it is not a self link.
+//
+// The location is "global", a region name, or a zone name, without the
+// "regions/" or "zones/" segment that precedes region and zone names.
+func parseGCPSelfLink(s string) (map[string]string, bool) {
+	path, ok := strings.CutPrefix(s, gcpSelfLinkBase)
+	if !ok {
+		return nil, false
+	}
+	segs := strings.Split(path, "/")
+	if len(segs) < 7 || segs[2] != "projects" {
+		return nil, false
+	}
+	ret := map[string]string{
+		"service": segs[0],
+		"version": segs[1],
+		"project": segs[3],
+	}
+	rest := segs[4:]
+	switch rest[0] {
+	case "global":
+		ret["location"] = "global"
+		rest = rest[1:]
+	case "regions", "zones":
+		ret["location"] = rest[1]
+		rest = rest[2:]
+	default:
+		return nil, false
+	}
+	if len(rest) < 2 {
+		return nil, false
+	}
+	ret["resource_type"] = rest[0]
+	ret["name"] = strings.Join(rest[1:], "/")
+	return ret, true
+}
+
+// gcpSelfLinkDiffs returns descriptions of each of the ways that the given
+// parsed self link differs from the assumed components.
+func gcpSelfLinkDiffs(actual map[string]string, comps map[string]cty.Value) []string {
+	var diffs []string
+	for _, name := range gcpComponentNames {
+		want, ok := comps[name]
+		if !ok || !want.IsKnown() {
+			continue
+		}
+		if got := actual[name]; got != want.AsString() {
+			diffs = append(diffs, fmt.Sprintf("%s is %s but was assumed to be %q", name, displayActualString(got), want.AsString()))
+		}
+	}
+	return diffs
+}
+
+// renderGCPSelfLink returns the longest prefix of a self link that the given
+// components imply, and whether that prefix is the entire self link.
+func renderGCPSelfLink(comps map[string]cty.Value) (string, bool) {
+	var buf strings.Builder
+	buf.WriteString(gcpSelfLinkBase)
+	for _, name := range gcpComponentNames {
+		val, ok := comps[name]
+		if !ok || !val.IsKnown() {
+			return buf.String(), false
+		}
+		s := val.AsString()
+		switch {
+		case name != "location":
+		case s == "global":
+		case gcpZonePattern.MatchString(s):
+			buf.WriteString("zones/")
+		default:
+			buf.WriteString("regions/")
+		}
+		buf.WriteString(s)
+		if name == "version" {
+			buf.WriteString("/projects")
+		}
+		if name != "name" {
+			buf.WriteByte('/')
+		}
+	}
+	return buf.String(), true
+}
+
+// checkPathComponents returns an error if any of the given known components
+// could not possibly appear in a single segment of a slash-separated path.
+func checkPathComponents(comps map[string]cty.Value, names []string) error {
+	for _, name := range names {
+		if val, ok := comps[name]; ok && val.IsKnown() {
+			s := val.AsString()
+			if s == "" {
+				return fmt.Errorf("component %q must not be empty", name)
+			}
+			if strings.Contains(s, "/") {
+				return fmt.Errorf("component %q must not contain slashes", name)
+			}
+		}
+	}
+	return nil
+}
+
+// hasPrefixFold is like strings.HasPrefix but ignores differences in case.
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
+
+// hasSuffixFold is like strings.HasSuffix but ignores differences in case.
+func hasSuffixFold(s, suffix string) bool {
+	return len(s) >= len(suffix) && strings.EqualFold(s[len(s)-len(suffix):], suffix)
+}
+
+// isUUID returns true if the given string is a UUID in its usual hyphenated
+// hexadecimal form, in either case.
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i, c := range s {
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return false
+			}
+		default:
+			if !isHexDigit(c) {
+				return false
+			}
+		}
+	}
+	return true
+}
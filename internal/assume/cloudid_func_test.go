@@ -0,0 +1,484 @@
+package assume
+
+import (
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestCloudIDFuncs(t *testing.T) {
+	roleComponents := cty.ObjectVal(map[string]cty.Value{
+		"partition":     cty.StringVal("aws"),
+		"service":       cty.StringVal("iam"),
+		"region":        cty.StringVal(""),
+		"account":       cty.StringVal("123456789012"),
+		"resource_type": cty.StringVal("role/"),
+		"resource":      cty.StringVal("example"),
+	})
+
+	const vnetID = "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/example/providers/Microsoft.Network/virtualNetworks/example"
+	vnetComponents := cty.ObjectVal(map[string]cty.Value{
+		"subscription":   cty.StringVal("00000000-0000-0000-0000-000000000000"),
+		"resource_group": cty.StringVal("example"),
+		"provider":       cty.StringVal("Microsoft.Network"),
+		"resource_type":  cty.StringVal("virtualNetworks"),
+		"name":           cty.StringVal("example"),
+	})
+	const subnetLink = "https://www.googleapis.com/compute/v1/projects/example/regions/us-central1/subnetworks/example"
+	subnetComponents := cty.ObjectVal(map[string]cty.Value{
+		"service":       cty.StringVal("compute"),
+		"version":       cty.StringVal("v1"),
+		"project":       cty.StringVal("example"),
+		"location":      cty.StringVal("us-central1"),
+		"resource_type": cty.StringVal("subnetworks"),
+		"name":          cty.StringVal("example"),
+	})
+
+	testProviderFuncs(t, funcTests{
+		"awsarn": {
+			"all components known": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					roleComponents,
+				},
+				Want: cty.StringVal("arn:aws:iam::123456789012:role/example"),
+			},
+			"resource without resource type": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.ObjectVal(map[string]cty.Value{
+						"partition": cty.StringVal("aws"),
+						"service":   cty.StringVal("s3"),
+						"region":    cty.StringVal(""),
+						"account":   cty.StringVal(""),
+						"resource":  cty.StringVal("example-bucket"),
+					}),
+				},
+				Want: cty.StringVal("arn:aws:s3:::example-bucket"),
+			},
+			"some components unknown": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.ObjectVal(map[string]cty.Value{
+						"partition":     cty.StringVal("aws"),
+						"service":       cty.StringVal("iam"),
+						"region":        cty.StringVal(""),
+						"account":       cty.UnknownVal(cty.String),
+						"resource_type": cty.StringVal("role/"),
+					}),
+				},
+				Want: cty.UnknownVal(cty.String).Refine().
+					NotNull().
+					StringPrefixFull("arn:aws:iam::").
+					NewValue(),
+			},
+			"some components omitted": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.ObjectVal(map[string]cty.Value{
+						"partition": cty.StringVal("aws"),
+						"service":   cty.StringVal("lambda"),
+						"account":   cty.StringVal("123456789012"),
+					}),
+				},
+				Want: cty.UnknownVal(cty.String).Refine().
+					NotNull().
+					StringPrefixFull("arn:aws:lambda:").
+					NewValue(),
+			},
+			"components unknown": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.DynamicVal,
+				},
+				Want: cty.UnknownVal(cty.String).Refine().
+					NotNull().
+					StringPrefixFull("arn:").
+					NewValue(),
+			},
+			"unknown string with conflicting prefix": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String).Refine().StringPrefixFull("arn:aws-cn:").NewValue(),
+					cty.ObjectVal(map[string]cty.Value{
+						"partition": cty.StringVal("aws"),
+					}),
+				},
+				WantErr: `value already known to start with "arn:aws-cn:" which conflicts with assumed prefix "arn:aws:", implied by the assumed ARN components`,
+			},
+			"unknown string with conflicting prefix and all components known": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String).Refine().StringPrefixFull("arn:aws-cn:").NewValue(),
+					roleComponents,
+				},
+				WantErr: `value (a string starting with "arn:aws-cn:") does not match the assumed ARN "arn:aws:iam::123456789012:role/example"`,
+			},
+			"known ARN that matches": {
+				Args: []cty.Value{
+					cty.StringVal("arn:aws:iam::123456789012:role/example"),
+					roleComponents,
+				},
+				Want: cty.StringVal("arn:aws:iam::123456789012:role/example"),
+			},
+			"known ARN that matches unknown components": {
+				Args: []cty.Value{
+					cty.StringVal("arn:aws:lambda:us-west-2:123456789012:function:example"),
+					cty.ObjectVal(map[string]cty.Value{
+						"partition":     cty.StringVal("aws"),
+						"service":       cty.StringVal("lambda"),
+						"region":        cty.UnknownVal(cty.String),
+						"account":       cty.UnknownVal(cty.String),
+						"resource_type": cty.StringVal("function:"),
+						"resource":      cty.UnknownVal(cty.String),
+					}),
+				},
+				Want: cty.StringVal("arn:aws:lambda:us-west-2:123456789012:function:example"),
+			},
+			"known ARN with differing components": {
+				Args: []cty.Value{
+					cty.StringVal("arn:aws:iam::123456789012:user/example"),
+					cty.ObjectVal(map[string]cty.Value{
+						"partition":     cty.StringVal("aws"),
+						"service":       cty.StringVal("iam"),
+						"account":       cty.StringVal("210987654321"),
+						"resource_type": cty.StringVal("role/"),
+						"resource":      cty.StringVal("example"),
+					}),
+				},
+				WantErr: "value \"arn:aws:iam::123456789012:user/example\" does not match the assumed ARN:\n  account is \"123456789012\" but was assumed to be \"210987654321\"\n  resource_type is \"user/\" but was assumed to be \"role/\"",
+			},
+			"known ARN with invalid account id": {
+				Args: []cty.Value{
+					cty.StringVal("arn:aws:iam::12345:role/example"),
+					cty.ObjectVal(map[string]cty.Value{
+						"account": cty.UnknownVal(cty.String),
+					}),
+				},
+				WantErr: "value \"arn:aws:iam::12345:role/example\" does not match the assumed ARN:\n  account is \"12345\" but was assumed to be a 12-digit account id",
+			},
+			"known ARN with AWS-managed account and account omitted": {
+				// The account format is only checked when the account
+				// component is given, because AWS-managed resources use
+				// "aws" as the account.
+				Args: []cty.Value{
+					cty.StringVal("arn:aws:iam::aws:policy/ReadOnlyAccess"),
+					cty.ObjectVal(map[string]cty.Value{
+						"service":       cty.StringVal("iam"),
+						"resource_type": cty.StringVal("policy/"),
+					}),
+				},
+				Want: cty.StringVal("arn:aws:iam::aws:policy/ReadOnlyAccess"),
+			},
+			"known ARN without resource type": {
+				Args: []cty.Value{
+					cty.StringVal("arn:aws:s3:::example-bucket"),
+					cty.ObjectVal(map[string]cty.Value{
+						"resource_type": cty.StringVal("role/"),
+					}),
+				},
+				WantErr: "value \"arn:aws:s3:::example-bucket\" does not match the assumed ARN:\n  resource_type is missing but was assumed to be \"role/\"",
+			},
+			"known string that isn't an ARN": {
+				Args: []cty.Value{
+					cty.StringVal("example"),
+					cty.EmptyObjectVal,
+				},
+				WantErr: `value "example" is not an ARN, which must have the form arn:PARTITION:SERVICE:REGION:ACCOUNT:RESOURCE`,
+			},
+			"null string": {
+				Args: []cty.Value{
+					cty.NullVal(cty.String),
+					cty.EmptyObjectVal,
+				},
+				WantErr: "value is null but was assumed to be an ARN",
+			},
+			"unsupported component": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.ObjectVal(map[string]cty.Value{
+						"acount": cty.StringVal("123456789012"),
+					}),
+				},
+				WantErr: `unsupported component "acount"; must be one of partition, service, region, account, resource_type, resource`,
+			},
+			"component with colon": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.ObjectVal(map[string]cty.Value{
+						"service": cty.StringVal("iam:"),
+					}),
+				},
+				WantErr: `component "service" must not contain colons`,
+			},
+			"resource type without separator": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.ObjectVal(map[string]cty.Value{
+						"resource_type": cty.StringVal("role"),
+					}),
+				},
+				WantErr: `component "resource_type" must be a resource type followed by its separator, like "role/" or "function:"`,
+			},
+		},
+		"azureresourceid": {
+			"all components known": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					vnetComponents,
+				},
+				Want: cty.StringVal(vnetID),
+			},
+			"some components unknown": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.ObjectVal(map[string]cty.Value{
+						"subscription":   cty.StringVal("00000000-0000-0000-0000-000000000000"),
+						"resource_group": cty.UnknownVal(cty.String),
+						"provider":       cty.StringVal("Microsoft.Network"),
+					}),
+				},
+				// The prefix stops at the first letter, because Azure might
+				// return the rest of the id with different case.
+				Want: cty.UnknownVal(cty.String).Refine().
+					NotNull().
+					StringPrefixFull("/subscriptions/00000000-0000-0000-0000-000000000000/").
+					NewValue(),
+			},
+			"subscription with letters": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.ObjectVal(map[string]cty.Value{
+						"subscription": cty.StringVal("0000000a-0000-0000-0000-000000000000"),
+					}),
+				},
+				Want: cty.UnknownVal(cty.String).Refine().
+					NotNull().
+					StringPrefixFull("/subscriptions/000000").
+					NewValue(),
+			},
+			"components unknown": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.DynamicVal,
+				},
+				Want: cty.UnknownVal(cty.String).Refine().
+					NotNull().
+					StringPrefixFull("/subscriptions/").
+					NewValue(),
+			},
+			"unknown string with prefix differing only in case": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String).Refine().StringPrefixFull("/subscriptions/00000000-0000-0000-0000-000000000000/resourcegroups/").NewValue(),
+					vnetComponents,
+				},
+				Want: cty.StringVal(vnetID),
+			},
+			"unknown string with conflicting prefix and all components known": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String).Refine().StringPrefixFull("/providers/").NewValue(),
+					vnetComponents,
+				},
+				WantErr: `value (a string starting with "/providers/") does not match the assumed Azure resource id "` + vnetID + `"`,
+			},
+			"known id that matches": {
+				Args: []cty.Value{
+					cty.StringVal(vnetID),
+					vnetComponents,
+				},
+				Want: cty.StringVal(vnetID),
+			},
+			"known id that differs only in case": {
+				Args: []cty.Value{
+					cty.StringVal("/subscriptions/00000000-0000-0000-0000-000000000000/resourcegroups/EXAMPLE/providers/Microsoft.Network/virtualnetworks/example"),
+					vnetComponents,
+				},
+				// The result uses the case from the components, so that
+				// it's consistent with the result during planning.
+				Want: cty.StringVal(vnetID),
+			},
+			"known id that matches unknown components": {
+				Args: []cty.Value{
+					cty.StringVal(vnetID + "/subnets/default"),
+					cty.ObjectVal(map[string]cty.Value{
+						"subscription":   cty.UnknownVal(cty.String),
+						"resource_group": cty.StringVal("example"),
+						"provider":       cty.StringVal("microsoft.network"),
+						"resource_type":  cty.StringVal("virtualNetworks"),
+						"name":           cty.UnknownVal(cty.String),
+					}),
+				},
+				Want: cty.StringVal(vnetID + "/subnets/default"),
+			},
+			"known id with fixed segments in different case": {
+				Args: []cty.Value{
+					cty.StringVal("/SUBSCRIPTIONS/00000000-0000-0000-0000-000000000000/resourceGroups/example"),
+					cty.ObjectVal(map[string]cty.Value{
+						"subscription":   cty.UnknownVal(cty.String),
+						"resource_group": cty.StringVal("example"),
+					}),
+				},
+				WantErr: `value "/SUBSCRIPTIONS/00000000-0000-0000-0000-000000000000/resourceGroups/example" does not start with "/subscriptions/", implied by the assumed Azure resource id components`,
+			},
+			"known id with differing components": {
+				Args: []cty.Value{
+					cty.StringVal(vnetID),
+					cty.ObjectVal(map[string]cty.Value{
+						"subscription":  cty.UnknownVal(cty.String),
+						"resource_type": cty.StringVal("networkSecurityGroups"),
+						"name":          cty.StringVal("example"),
+					}),
+				},
+				WantErr: "value \"" + vnetID + "\" does not match the assumed Azure resource id:\n  resource_type is \"virtualNetworks\" but was assumed to be \"networkSecurityGroups\"",
+			},
+			"known resource group id": {
+				Args: []cty.Value{
+					cty.StringVal("/subscriptions/example/resourceGroups/example"),
+					cty.ObjectVal(map[string]cty.Value{
+						"subscription":  cty.UnknownVal(cty.String),
+						"provider":      cty.UnknownVal(cty.String),
+						"resource_type": cty.StringVal("virtualNetworks"),
+					}),
+				},
+				WantErr: "value \"/subscriptions/example/resourceGroups/example\" does not match the assumed Azure resource id:\n  subscription is \"example\" but was assumed to be a subscription id\n  provider is missing but was assumed to be present\n  resource_type is missing but was assumed to be \"virtualNetworks\"",
+			},
+			"known string that isn't an Azure resource id": {
+				Args: []cty.Value{
+					cty.StringVal("/subscriptions/example"),
+					cty.EmptyObjectVal,
+				},
+				WantErr: `value "/subscriptions/example" is not an Azure resource id, which must have the form /subscriptions/SUBSCRIPTION/resourceGroups/RESOURCE_GROUP/providers/PROVIDER/RESOURCE_TYPE/NAME`,
+			},
+			"null string": {
+				Args: []cty.Value{
+					cty.NullVal(cty.String),
+					cty.EmptyObjectVal,
+				},
+				WantErr: "value is null but was assumed to be an Azure resource id",
+			},
+			"component with slash": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.ObjectVal(map[string]cty.Value{
+						"resource_type": cty.StringVal("virtualNetworks/subnets"),
+					}),
+				},
+				WantErr: `component "resource_type" must not contain slashes`,
+			},
+		},
+		"gcpselflink": {
+			"all components known": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					subnetComponents,
+				},
+				Want: cty.StringVal(subnetLink),
+			},
+			"zonal resource": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.ObjectVal(map[string]cty.Value{
+						"service":       cty.StringVal("compute"),
+						"version":       cty.StringVal("v1"),
+						"project":       cty.StringVal("example"),
+						"location":      cty.StringVal("us-central1-a"),
+						"resource_type": cty.StringVal("instances"),
+						"name":          cty.StringVal("example"),
+					}),
+				},
+				Want: cty.StringVal("https://www.googleapis.com/compute/v1/projects/example/zones/us-central1-a/instances/example"),
+			},
+			"global resource": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.ObjectVal(map[string]cty.Value{
+						"service":       cty.StringVal("compute"),
+						"version":       cty.StringVal("v1"),
+						"project":       cty.StringVal("example"),
+						"location":      cty.StringVal("global"),
+						"resource_type": cty.StringVal("networks"),
+						"name":          cty.StringVal("example"),
+					}),
+				},
+				Want: cty.StringVal("https://www.googleapis.com/compute/v1/projects/example/global/networks/example"),
+			},
+			"some components unknown": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.ObjectVal(map[string]cty.Value{
+						"service":  cty.StringVal("compute"),
+						"version":  cty.StringVal("v1"),
+						"project":  cty.StringVal("example"),
+						"location": cty.StringVal("us-central1"),
+						"name":     cty.UnknownVal(cty.String),
+					}),
+				},
+				Want: cty.UnknownVal(cty.String).Refine().
+					NotNull().
+					StringPrefixFull("https://www.googleapis.com/compute/v1/projects/example/regions/us-central1/").
+					NewValue(),
+			},
+			"unknown string with conflicting prefix": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String).Refine().StringPrefixFull("https://www.googleapis.com/storage/").NewValue(),
+					cty.ObjectVal(map[string]cty.Value{
+						"service": cty.StringVal("compute"),
+					}),
+				},
+				WantErr: `value already known to start with "https://www.googleapis.com/storage/" which conflicts with assumed prefix "https://www.googleapis.com/compute/", implied by the assumed self link components`,
+			},
+			"known self link that matches": {
+				Args: []cty.Value{
+					cty.StringVal(subnetLink),
+					subnetComponents,
+				},
+				Want: cty.StringVal(subnetLink),
+			},
+			"known self link with zone under regions": {
+				Args: []cty.Value{
+					cty.StringVal("https://www.googleapis.com/compute/v1/projects/example/regions/us-central1-a/instances/example"),
+					cty.ObjectVal(map[string]cty.Value{
+						"service":  cty.StringVal("compute"),
+						"version":  cty.StringVal("v1"),
+						"project":  cty.StringVal("example"),
+						"location": cty.StringVal("us-central1-a"),
+						"name":     cty.UnknownVal(cty.String),
+					}),
+				},
+				WantErr: `value "https://www.googleapis.com/compute/v1/projects/example/regions/us-central1-a/instances/example" does not start with "https://www.googleapis.com/compute/v1/projects/example/zones/us-central1-a/", implied by the assumed self link components`,
+			},
+			"known self link with differing components": {
+				Args: []cty.Value{
+					cty.StringVal(subnetLink),
+					cty.ObjectVal(map[string]cty.Value{
+						"project":  cty.StringVal("other"),
+						"location": cty.StringVal("global"),
+						"name":     cty.UnknownVal(cty.String),
+					}),
+				},
+				WantErr: "value \"" + subnetLink + "\" does not match the assumed self link:\n  project is \"example\" but was assumed to be \"other\"\n  location is \"us-central1\" but was assumed to be \"global\"",
+			},
+			"known string that isn't a self link": {
+				Args: []cty.Value{
+					cty.StringVal("projects/example/regions/us-central1/subnetworks/example"),
+					cty.EmptyObjectVal,
+				},
+				WantErr: `value "projects/example/regions/us-central1/subnetworks/example" is not a self link, which must have the form https://www.googleapis.com/SERVICE/VERSION/projects/PROJECT/LOCATION/RESOURCE_TYPE/NAME`,
+			},
+			"null string": {
+				Args: []cty.Value{
+					cty.NullVal(cty.String),
+					cty.EmptyObjectVal,
+				},
+				WantErr: "value is null but was assumed to be a self link",
+			},
+			"empty component": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.ObjectVal(map[string]cty.Value{
+						"project": cty.StringVal(""),
+					}),
+				},
+				WantErr: `component "project" must not be empty`,
+			},
+		},
+	})
+}
@@ -0,0 +1,48 @@
+package assume
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// stringComponents returns the string values of the components described by
+// an object or map argument, keyed by component name, for functions that
+// assume something about a structured string such as an ARN.
+//
+// Components that are omitted or null are not included in the result, and
+// an unknown argument is treated as if all of the components were omitted.
+// Unknown component values are included, so callers must check for them
+// before using the string values.
+func stringComponents(arg cty.Value, names []string) (map[string]cty.Value, error) {
+	ty := arg.Type()
+	if !(ty.IsObjectType() || ty.IsMapType() || ty == cty.DynamicPseudoType) {
+		return nil, fmt.Errorf("must be an object describing the components")
+	}
+	ret := make(map[string]cty.Value)
+	if !arg.IsKnown() {
+		return ret, nil
+	}
+	if arg.IsNull() {
+		return nil, fmt.Errorf("must not be null")
+	}
+	for it := arg.ElementIterator(); it.Next(); {
+		k, val := it.Element()
+		name := k.AsString()
+		if !slices.Contains(names, name) {
+			return nil, fmt.Errorf("unsupported component %q; must be one of %s", name, strings.Join(names, ", "))
+		}
+		val, err := convert.Convert(val, cty.String)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for component %q: %w", name, err)
+		}
+		if val.IsKnown() && val.IsNull() {
+			continue
+		}
+		ret[name] = val
+	}
+	return ret, nil
+}
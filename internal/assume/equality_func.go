@@ -0,0 +1,249 @@
+package assume
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	"github.com/zclconf/go-cty/cty/function"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+var jsonequalFunc = makeEqualityFunc(
+	cty.String,
+	"Assume that the given JSON string will be equivalent to the given JSON string, ignoring differences in whitespace and object property order.",
+	func(args []cty.Value) string {
+		return "after normalizing both as JSON"
+	},
+	func(args []cty.Value) error {
+		if args[1].IsNull() {
+			return nil
+		}
+		if _, err := decodeJSONForComparison(args[1].AsString()); err != nil {
+			return function.NewArgErrorf(1, "must be valid JSON: %s", err)
+		}
+		return nil
+	},
+	func(actual, assumed cty.Value, args []cty.Value) (bool, []string) {
+		if actual.IsNull() || assumed.IsNull() {
+			return actual.IsNull() && assumed.IsNull(), nil
+		}
+		actualVal, err := decodeJSONForComparison(actual.AsString())
+		if err != nil {
+			if redaction != redactNone {
+				// JSON syntax errors can include parts of the input.
+				return false, []string{"value: not valid JSON"}
+			}
+			return false, []string{fmt.Sprintf("value: not valid JSON: %s", err)}
+		}
+		// The assumed value was already validated by the checkArgs callback.
+		assumedVal, _ := decodeJSONForComparison(assumed.AsString())
+		diffs := valueDiff(actualVal, assumedVal)
+		return len(diffs) == 0, diffs
+	},
+)
+
+var equalfoldFunc = makeEqualityFunc(
+	cty.String,
+	"Assume that the given string will be equal to the given string, ignoring differences in letter case.",
+	func(args []cty.Value) string {
+		return "when compared case-insensitively"
+	},
+	nil,
+	func(actual, assumed cty.Value, args []cty.Value) (bool, []string) {
+		if actual.IsNull() || assumed.IsNull() {
+			return actual.IsNull() && assumed.IsNull(), nil
+		}
+		return strings.EqualFold(actual.AsString(), assumed.AsString()), nil
+	},
+)
+
+var unorderedequalFunc = makeEqualityFunc(
+	cty.DynamicPseudoType,
+	"Assume that the given list or tuple will have the same elements as the given list or tuple, ignoring the order of the elements.",
+	func(args []cty.Value) string {
+		return "when ignoring element order"
+	},
+	func(args []cty.Value) error {
+		for i, arg := range args {
+			if ty := arg.Type(); !(ty.IsListType() || ty.IsTupleType() || ty == cty.DynamicPseudoType) {
+				return function.NewArgErrorf(i, "must be a list or tuple")
+			}
+		}
+		return nil
+	},
+	func(actual, assumed cty.Value, args []cty.Value) (bool, []string) {
+		if actual.IsNull() || assumed.IsNull() {
+			return actual.IsNull() && assumed.IsNull(), nil
+		}
+		unexpected, missing := unmatchedElements(actual.AsValueSlice(), assumed.AsValueSlice())
+		var diffs []string
+		for _, elem := range unexpected {
+			diffs = append(diffs, "value: unexpected element "+diffDisplayValue(elem))
+		}
+		for _, elem := range missing {
+			diffs = append(diffs, "value: missing element "+diffDisplayValue(elem))
+		}
+		return len(diffs) == 0, diffs
+	},
+)
+
+var equalwithinFunc = makeEqualityFunc(
+	cty.Number,
+	"Assume that the given number will be equal to the given number, within the given tolerance.",
+	func(args []cty.Value) string {
+		return "within a tolerance of " + simpleDisplayValue(args[2])
+	},
+	func(args []cty.Value) error {
+		if args[2].LessThan(cty.Zero).True() {
+			return function.NewArgErrorf(2, "must not be negative")
+		}
+		return nil
+	},
+	func(actual, assumed cty.Value, args []cty.Value) (bool, []string) {
+		if actual.IsNull() || assumed.IsNull() {
+			return actual.IsNull() && assumed.IsNull(), nil
+		}
+		diff := new(big.Float).Sub(actual.AsBigFloat(), assumed.AsBigFloat())
+		return diff.Abs(diff).Cmp(args[0].AsBigFloat()) <= 0, nil
+	},
+	function.Parameter{
+		Name:        "tolerance",
+		Type:        cty.Number,
+		Description: "The maximum allowed difference between the actual value and the assumed value.",
+	},
+)
+
+// makeEqualityFunc builds a function that behaves like "equal" but uses a
+// more lenient definition of equality, implemented by the given equivalent
+// callback.
+//
+// The actual value and the assumed value both have the given type
+// constraint. If that is cty.DynamicPseudoType then the two values might
+// have different types and the equivalent callback must decide whether
+// values of different types can be equivalent. The equivalent callback
+// receives only wholly-known values, along with any additional arguments
+// corresponding to the given params, and returns whether the values are
+// equivalent along with an optional description of each of the differences
+// between them.
+//
+// Unlike most of our other function builders, the checkArgs callback
+// receives all of the arguments, including the actual and assumed values.
+//
+// The normalization callback describes the lenient definition of equality
+// in a way that can be appended to an error message, like "when compared
+// case-insensitively".
+func makeEqualityFunc(ty cty.Type, desc string, normalization func(args []cty.Value) string, checkArgs func([]cty.Value) error, equivalent func(actual, assumed cty.Value, args []cty.Value) (bool, []string), params ...function.Parameter) *function.Spec {
+	spec := &function.Spec{
+		Description: desc,
+		Params: []function.Parameter{
+			{
+				Name:             "actual_value",
+				Type:             ty,
+				Description:      "The value to make the assumption about.",
+				AllowNull:        true,
+				AllowUnknown:     true,
+				AllowDynamicType: true,
+			},
+			{
+				Name:             "assumed_value",
+				Type:             ty,
+				Description:      "The value that the first argument is assumed to match.",
+				AllowNull:        true,
+				AllowUnknown:     true, // we reject unknowns as an error, though
+				AllowDynamicType: true,
+			},
+		},
+		Type: func(args []cty.Value) (cty.Type, error) {
+			return args[1].Type(), nil
+		},
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			if !args[1].IsWhollyKnown() {
+				return cty.UnknownVal(retType), function.NewArgErrorf(1, "the second argument must be something known during the planning phase")
+			}
+			if checkArgs != nil {
+				if err := checkArgs(args); err != nil {
+					return cty.UnknownVal(retType), err
+				}
+			}
+			if !args[0].IsWhollyKnown() {
+				// We can't check the assumption until the actual value is
+				// known, so we just return the assumed value for now.
+				return args[1], nil
+			}
+			ok, diffs := equivalent(args[0], args[1], args[2:])
+			if ok {
+				return args[1], nil
+			}
+			return cty.UnknownVal(retType), function.NewArgError(0, equalityMismatchError(args[0], args[1], normalization(args), diffs))
+		},
+	}
+	spec.Params = append(spec.Params, params...)
+	return spec
+}
+
+// equalityMismatchError returns an error describing that the given actual
+// value does not match the given assumed value, mentioning the normalization
+// that was used to compare them and any detailed differences between them.
+func equalityMismatchError(actual, assumed cty.Value, normalization string, diffs []string) error {
+	var msg strings.Builder
+	msg.WriteString("the actual value")
+	if vStr := displayActualValue(actual); vStr != "" && len(diffs) == 0 {
+		msg.WriteString(" " + vStr)
+	}
+	msg.WriteString(" does not match the assumed value")
+	if vStr := displayActualValue(assumed); vStr != "" && len(diffs) == 0 {
+		msg.WriteString(" " + vStr)
+	}
+	if normalization != "" {
+		msg.WriteString(" " + normalization)
+	}
+	if len(diffs) != 0 {
+		msg.WriteString(":\n  ")
+		msg.WriteString(strings.Join(diffs, "\n  "))
+	}
+	return fmt.Errorf("%s", msg.String())
+}
+
+// decodeJSONForComparison decodes the given JSON string into a cty value that
+// can be compared with other values decoded in the same way.
+func decodeJSONForComparison(src string) (cty.Value, error) {
+	buf := []byte(src)
+	ty, err := ctyjson.ImpliedType(buf)
+	if err != nil {
+		return cty.NilVal, err
+	}
+	return ctyjson.Unmarshal(buf, ty)
+}
+
+// unmatchedElements pairs up the equal elements of the two given slices,
+// ignoring their order, and returns the elements of each that have no
+// equal counterpart in the other.
+func unmatchedElements(actual, assumed []cty.Value) (unexpected, missing []cty.Value) {
+	matched := make([]bool, len(assumed))
+Actual:
+	for _, a := range actual {
+		for i, e := range assumed {
+			if matched[i] {
+				continue
+			}
+			// The elements of a tuple can have different types, so we
+			// allow an actual element to match any assumed element that
+			// it can convert to.
+			a, err := convert.Convert(a, e.Type())
+			if err == nil && a.Equals(e).True() {
+				matched[i] = true
+				continue Actual
+			}
+		}
+		unexpected = append(unexpected, a)
+	}
+	for i, e := range assumed {
+		if !matched[i] {
+			missing = append(missing, e)
+		}
+	}
+	return unexpected, missing
+}
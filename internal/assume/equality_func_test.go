@@ -0,0 +1,179 @@
+package assume
+
+import (
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestEqualityFuncs(t *testing.T) {
+	testProviderFuncs(t, funcTests{
+		"jsonequal": {
+			"unknown string": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.StringVal(`{"a":1,"b":2}`),
+				},
+				Want: cty.StringVal(`{"a":1,"b":2}`),
+			},
+			"unknown assumed value": {
+				Args: []cty.Value{
+					cty.StringVal(`{}`),
+					cty.UnknownVal(cty.String),
+				},
+				WantErr: "the second argument must be something known during the planning phase",
+			},
+			"invalid assumed value": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.StringVal(`{"a" 1}`),
+				},
+				WantErr: "must be valid JSON: invalid character '1' after object key",
+			},
+			"equivalent with different key order and whitespace": {
+				Args: []cty.Value{
+					cty.StringVal("{\n  \"b\": [1, 2.0],\n  \"a\": true\n}"),
+					cty.StringVal(`{"a":true,"b":[1,2]}`),
+				},
+				Want: cty.StringVal(`{"a":true,"b":[1,2]}`),
+			},
+			"different": {
+				Args: []cty.Value{
+					cty.StringVal(`{"Statement":[{"Effect":"Deny","Sid":"x"}]}`),
+					cty.StringVal(`{"Statement":[{"Effect":"Allow"}]}`),
+				},
+				WantErr: "the actual value does not match the assumed value after normalizing both as JSON:\n  .Statement[0].Effect: \"Deny\" vs \"Allow\"\n  .Statement[0]: unexpected attribute \"Sid\"",
+			},
+			"invalid actual value": {
+				Args: []cty.Value{
+					cty.StringVal(`not json`),
+					cty.StringVal(`{}`),
+				},
+				WantErr: "the actual value does not match the assumed value after normalizing both as JSON:\n  value: not valid JSON: invalid character 'o' in literal null (expecting 'u')",
+			},
+			"null actual value": {
+				Args: []cty.Value{
+					cty.NullVal(cty.String),
+					cty.StringVal(`{}`),
+				},
+				WantErr: "the actual value null does not match the assumed value \"{}\" after normalizing both as JSON",
+			},
+		},
+		"equalfold": {
+			"unknown string": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.StringVal("MyBucket"),
+				},
+				Want: cty.StringVal("MyBucket"),
+			},
+			"equal except for case": {
+				Args: []cty.Value{
+					cty.StringVal("mybucket"),
+					cty.StringVal("MyBucket"),
+				},
+				Want: cty.StringVal("MyBucket"),
+			},
+			"different": {
+				Args: []cty.Value{
+					cty.StringVal("otherbucket"),
+					cty.StringVal("MyBucket"),
+				},
+				WantErr: "the actual value \"otherbucket\" does not match the assumed value \"MyBucket\" when compared case-insensitively",
+			},
+		},
+		"unorderedequal": {
+			"unknown list": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.List(cty.String)),
+					cty.TupleVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b")}),
+				},
+				Want: cty.TupleVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b")}),
+			},
+			"partially-unknown list": {
+				Args: []cty.Value{
+					cty.ListVal([]cty.Value{cty.StringVal("b"), cty.UnknownVal(cty.String)}),
+					cty.TupleVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b")}),
+				},
+				Want: cty.TupleVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b")}),
+			},
+			"same elements in different order": {
+				Args: []cty.Value{
+					cty.ListVal([]cty.Value{cty.StringVal("b"), cty.StringVal("a"), cty.StringVal("b")}),
+					cty.TupleVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b"), cty.StringVal("b")}),
+				},
+				Want: cty.TupleVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b"), cty.StringVal("b")}),
+			},
+			"tuple elements of different types": {
+				Args: []cty.Value{
+					cty.TupleVal([]cty.Value{cty.NumberIntVal(1), cty.StringVal("a")}),
+					cty.TupleVal([]cty.Value{cty.StringVal("a"), cty.NumberIntVal(1)}),
+				},
+				Want: cty.TupleVal([]cty.Value{cty.StringVal("a"), cty.NumberIntVal(1)}),
+			},
+			"different elements": {
+				Args: []cty.Value{
+					cty.ListVal([]cty.Value{cty.StringVal("b"), cty.StringVal("c")}),
+					cty.TupleVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b")}),
+				},
+				WantErr: "the actual value does not match the assumed value when ignoring element order:\n  value: unexpected element \"c\"\n  value: missing element \"a\"",
+			},
+			"duplicate elements": {
+				Args: []cty.Value{
+					cty.ListVal([]cty.Value{cty.StringVal("a"), cty.StringVal("a")}),
+					cty.TupleVal([]cty.Value{cty.StringVal("a")}),
+				},
+				WantErr: "the actual value does not match the assumed value when ignoring element order:\n  value: unexpected element \"a\"",
+			},
+			"not a list": {
+				Args: []cty.Value{
+					cty.StringVal("a"),
+					cty.TupleVal([]cty.Value{cty.StringVal("a")}),
+				},
+				WantErr: "must be a list or tuple",
+			},
+		},
+		"equalwithin": {
+			"unknown number": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.Number),
+					cty.NumberFloatVal(1.5),
+					cty.NumberFloatVal(0.01),
+				},
+				Want: cty.NumberFloatVal(1.5),
+			},
+			"within tolerance": {
+				Args: []cty.Value{
+					cty.NumberFloatVal(1.499),
+					cty.NumberFloatVal(1.5),
+					cty.NumberFloatVal(0.01),
+				},
+				Want: cty.NumberFloatVal(1.5),
+			},
+			"exactly at tolerance": {
+				Args: []cty.Value{
+					cty.NumberIntVal(3),
+					cty.NumberIntVal(2),
+					cty.NumberIntVal(1),
+				},
+				Want: cty.NumberIntVal(2),
+			},
+			"outside tolerance": {
+				Args: []cty.Value{
+					cty.NumberFloatVal(1.6),
+					cty.NumberFloatVal(1.5),
+					cty.NumberFloatVal(0.01),
+				},
+				WantErr: "the actual value 1.6 does not match the assumed value 1.5 within a tolerance of 0.01",
+			},
+			"negative tolerance": {
+				Args: []cty.Value{
+					cty.NumberFloatVal(1.5),
+					cty.NumberFloatVal(1.5),
+					cty.NumberFloatVal(-1),
+				},
+				WantErr: "must not be negative",
+			},
+		},
+	})
+}
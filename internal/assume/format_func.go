@@ -0,0 +1,212 @@
+package assume
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+)
+
+var uuidFunc = makeUUIDFunc()
+
+var lowerhexFunc = makeHexFunc("lowercase", "0123456789abcdef")
+
+var upperhexFunc = makeHexFunc("uppercase", "0123456789ABCDEF")
+
+var base64Func = makeStringFormatFunc(
+	"Assume that the given string will be valid standard base64, with padding.",
+	"base64",
+	nil,
+	func(s string, args []cty.Value) string {
+		if _, err := base64.StdEncoding.Strict().DecodeString(s); err != nil {
+			return fmt.Sprintf("is not valid base64: %s", err)
+		}
+		return ""
+	},
+)
+
+var base64urlFunc = makeStringFormatFunc(
+	"Assume that the given string will be valid URL-safe base64, with or without padding.",
+	"URL-safe base64",
+	nil,
+	func(s string, args []cty.Value) string {
+		enc := base64.URLEncoding
+		if !strings.HasSuffix(s, "=") {
+			enc = base64.RawURLEncoding
+		}
+		if _, err := enc.Strict().DecodeString(s); err != nil {
+			return fmt.Sprintf("is not valid URL-safe base64: %s", err)
+		}
+		return ""
+	},
+)
+
+var stringlengthFunc = makeStringFormatFunc(
+	"Assume that the given string will have exactly the given number of characters.",
+	"a string of a specific length",
+	checkStringLengthArg,
+	func(s string, args []cty.Value) string {
+		want, _ := args[0].AsBigFloat().Int64()
+		got, _ := stdlib.Strlen(cty.StringVal(s))
+		if n, _ := got.AsBigFloat().Int64(); n != want {
+			return fmt.Sprintf("has %d characters but was assumed to have %d", n, want)
+		}
+		return ""
+	},
+	function.Parameter{
+		Name:        "length",
+		Type:        cty.Number,
+		Description: "The number of characters, counted in the same way as Terraform's length function.",
+	},
+)
+
+// makeUUIDFunc builds the uuid function, which takes an optional trailing
+// version argument.
+func makeUUIDFunc() *function.Spec {
+	spec := makeStringFormatFunc(
+		"Assume that the given string will be a UUID, optionally of a specific version.",
+		"a UUID",
+		func(args []cty.Value) error {
+			if len(args) > 1 {
+				return function.NewArgErrorf(1, "too many arguments; only one version is allowed")
+			}
+			if len(args) == 1 {
+				if v, acc := args[0].AsBigFloat().Int64(); acc != big.Exact || v < 1 || v > 8 {
+					return function.NewArgErrorf(0, "must be a UUID version between 1 and 8")
+				}
+			}
+			return nil
+		},
+		func(s string, args []cty.Value) string {
+			if n := utf8.RuneCountInString(s); n != 36 {
+				return fmt.Sprintf("is not a UUID because it has %d characters instead of 36", n)
+			}
+			for i, c := range []rune(s) {
+				switch i {
+				case 8, 13, 18, 23:
+					if c != '-' {
+						return fmt.Sprintf("is not a UUID because character %d is not a hyphen", i+1)
+					}
+				default:
+					if !isHexDigit(c) {
+						return fmt.Sprintf("is not a UUID because character %d is not a hexadecimal digit", i+1)
+					}
+				}
+			}
+			if len(args) == 0 {
+				return ""
+			}
+			// Versioned UUIDs also use the variant from RFC 4122, which is
+			// represented in the first digit of the fourth group.
+			version, _ := args[0].AsBigFloat().Int64()
+			if s[14] != byte('0'+version) || !strings.ContainsRune("89abAB", rune(s[19])) {
+				return fmt.Sprintf("is not a version %d UUID", version)
+			}
+			return ""
+		},
+	)
+	spec.VarParam = &function.Parameter{
+		Name:        "version",
+		Type:        cty.Number,
+		Description: "The UUID version, such as 4 for randomly-generated UUIDs. Defaults to allowing any version.",
+	}
+	return spec
+}
+
+// makeHexFunc builds a function that assumes a string will consist of a
+// given number of hexadecimal digits, which are the given digits.
+func makeHexFunc(letterCase string, digits string) *function.Spec {
+	return makeStringFormatFunc(
+		"Assume that the given string will be the given number of "+letterCase+" hexadecimal digits.",
+		letterCase+" hexadecimal",
+		checkStringLengthArg,
+		func(s string, args []cty.Value) string {
+			want, _ := args[0].AsBigFloat().Int64()
+			if n := utf8.RuneCountInString(s); int64(n) != want {
+				return fmt.Sprintf("has %d characters but was assumed to have %d %s hexadecimal digits", n, want, letterCase)
+			}
+			for i, c := range []rune(s) {
+				if !strings.ContainsRune(digits, c) {
+					return fmt.Sprintf("is not %s hexadecimal because character %d is not one of %q", letterCase, i+1, digits)
+				}
+			}
+			return ""
+		},
+		function.Parameter{
+			Name:        "length",
+			Type:        cty.Number,
+			Description: "The number of hexadecimal digits, which is twice the number of bytes they represent.",
+		},
+	)
+}
+
+// makeStringFormatFunc builds a function that assumes a string will conform
+// to a format that cty's refinements can't represent.
+//
+// While the given value is unknown the function only refines it as not null.
+// Once the value is known the function calls check, which returns a
+// description of how the string fails to conform, or an empty string if it
+// does conform. The noun describes the format in the error for a null value.
+//
+// The checkArgs and check callbacks receive only the arguments after the
+// value, and checkArgs may be nil if there are no other arguments.
+func makeStringFormatFunc(desc string, noun string, checkArgs func(args []cty.Value) error, check func(s string, args []cty.Value) string, params ...function.Parameter) *function.Spec {
+	return &function.Spec{
+		Description: desc,
+		Params: append([]function.Parameter{
+			{
+				Name:         "value",
+				Type:         cty.String,
+				Description:  "The value to make the assumption about.",
+				AllowNull:    true,
+				AllowUnknown: true,
+			},
+		}, params...),
+		Type: function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			v := args[0]
+			if checkArgs != nil {
+				if err := checkArgs(args[1:]); err != nil {
+					if argErr, ok := err.(function.ArgError); ok {
+						argErr.Index++ // to account for the always-present extra "value" argument
+						err = argErr
+					}
+					return cty.UnknownVal(retType), err
+				}
+			}
+			if v.IsKnown() {
+				if v.IsNull() {
+					return cty.UnknownVal(retType), function.NewArgErrorf(0, "value is null but was assumed to be %s", noun)
+				}
+				if problem := check(v.AsString(), args[1:]); problem != "" {
+					return cty.UnknownVal(retType), function.NewArgErrorf(0, "value %s %s", displayActualValue(v), problem)
+				}
+				return v, nil
+			}
+			// cty can't represent the length or the allowed characters of an
+			// unknown string, so all we can say is that it won't be null.
+			return v.RefineNotNull(), nil
+		},
+	}
+}
+
+// checkStringLengthArg is a checkArgs callback for makeStringFormatFunc that
+// checks that a single length argument is a valid length.
+func checkStringLengthArg(args []cty.Value) error {
+	if v, acc := args[0].AsBigFloat().Int64(); acc != big.Exact || v < 0 || v >= math.MaxInt {
+		return function.NewArgErrorf(0, "must be a whole number between 0 and %d", math.MaxInt)
+	}
+	return nil
+}
+
+// isHexDigit returns true if the given character is a hexadecimal digit in
+// either case.
+func isHexDigit(c rune) bool {
+	return strings.ContainsRune("0123456789abcdefABCDEF", c)
+}
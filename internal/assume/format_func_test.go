@@ -0,0 +1,223 @@
+package assume
+
+import (
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestFormatFuncs(t *testing.T) {
+	const uuid4 = "6ba7b810-9dad-41d1-80b4-00c04fd430c8"
+
+	testProviderFuncs(t, funcTests{
+		"uuid": {
+			"unknown string": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+				},
+				Want: cty.UnknownVal(cty.String).RefineNotNull(),
+			},
+			"unknown string with version": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.NumberIntVal(4),
+				},
+				Want: cty.UnknownVal(cty.String).RefineNotNull(),
+			},
+			"known UUID": {
+				Args: []cty.Value{
+					cty.StringVal("6BA7B810-9DAD-11D1-80B4-00C04FD430C8"),
+				},
+				Want: cty.StringVal("6BA7B810-9DAD-11D1-80B4-00C04FD430C8"),
+			},
+			"known UUID of assumed version": {
+				Args: []cty.Value{
+					cty.StringVal(uuid4),
+					cty.NumberIntVal(4),
+				},
+				Want: cty.StringVal(uuid4),
+			},
+			"known UUID of other version": {
+				Args: []cty.Value{
+					cty.StringVal(uuid4),
+					cty.NumberIntVal(1),
+				},
+				WantErr: `value "` + uuid4 + `" is not a version 1 UUID`,
+			},
+			"known UUID with version and reason": {
+				Args: []cty.Value{
+					cty.StringVal(uuid4),
+					cty.NumberIntVal(7),
+					cty.StringVal("object ids are always time-ordered"),
+				},
+				WantErr: "value \"" + uuid4 + "\" is not a version 7 UUID\nReason for assumption: object ids are always time-ordered",
+			},
+			"known string of wrong length": {
+				Args: []cty.Value{
+					cty.StringVal("6ba7b810"),
+				},
+				WantErr: `value "6ba7b810" is not a UUID because it has 8 characters instead of 36`,
+			},
+			"known string without hyphens": {
+				Args: []cty.Value{
+					cty.StringVal("6ba7b8109dad41d180b400c04fd430c8abcd"),
+				},
+				WantErr: `value "6ba7b8109dad41d180b400c04fd430c8abcd" is not a UUID because character 9 is not a hyphen`,
+			},
+			"known string with non-hex digit": {
+				Args: []cty.Value{
+					cty.StringVal("6ba7b810-9dad-41d1-80b4-00c04fd430cg"),
+				},
+				WantErr: `value "6ba7b810-9dad-41d1-80b4-00c04fd430cg" is not a UUID because character 36 is not a hexadecimal digit`,
+			},
+			"null string": {
+				Args: []cty.Value{
+					cty.NullVal(cty.String),
+				},
+				WantErr: "value is null but was assumed to be a UUID",
+			},
+			"invalid version": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.NumberIntVal(9),
+				},
+				WantErr: "must be a UUID version between 1 and 8",
+			},
+		},
+		"lowerhex": {
+			"unknown string": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.NumberIntVal(64),
+				},
+				Want: cty.UnknownVal(cty.String).RefineNotNull(),
+			},
+			"known digest": {
+				Args: []cty.Value{
+					cty.StringVal("0123456789abcdef"),
+					cty.NumberIntVal(16),
+				},
+				Want: cty.StringVal("0123456789abcdef"),
+			},
+			"known digest of wrong length": {
+				Args: []cty.Value{
+					cty.StringVal("0123456789abcdef"),
+					cty.NumberIntVal(64),
+				},
+				WantErr: `value "0123456789abcdef" has 16 characters but was assumed to have 64 lowercase hexadecimal digits`,
+			},
+			"known digest in wrong case": {
+				Args: []cty.Value{
+					cty.StringVal("0123456789ABCDEF"),
+					cty.NumberIntVal(16),
+				},
+				WantErr: `value "0123456789ABCDEF" is not lowercase hexadecimal because character 11 is not one of "0123456789abcdef"`,
+			},
+			"invalid length": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.NumberFloatVal(1.5),
+				},
+				WantErr: "must be a whole number between 0 and 9223372036854775807",
+			},
+		},
+		"upperhex": {
+			"known digest": {
+				Args: []cty.Value{
+					cty.StringVal("0123456789ABCDEF"),
+					cty.NumberIntVal(16),
+				},
+				Want: cty.StringVal("0123456789ABCDEF"),
+			},
+			"known digest in wrong case": {
+				Args: []cty.Value{
+					cty.StringVal("0123456789abcdef"),
+					cty.NumberIntVal(16),
+				},
+				WantErr: `value "0123456789abcdef" is not uppercase hexadecimal because character 11 is not one of "0123456789ABCDEF"`,
+			},
+		},
+		"base64": {
+			"unknown string": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+				},
+				Want: cty.UnknownVal(cty.String).RefineNotNull(),
+			},
+			"known base64": {
+				Args: []cty.Value{
+					cty.StringVal("aGk+Pz8="),
+				},
+				Want: cty.StringVal("aGk+Pz8="),
+			},
+			"known base64 without padding": {
+				Args: []cty.Value{
+					cty.StringVal("aGk+Pz8"),
+				},
+				WantErr: `value "aGk+Pz8" is not valid base64: illegal base64 data at input byte 4`,
+			},
+			"known URL-safe base64": {
+				Args: []cty.Value{
+					cty.StringVal("aGk-Pz8="),
+				},
+				WantErr: `value "aGk-Pz8=" is not valid base64: illegal base64 data at input byte 3`,
+			},
+			"null string": {
+				Args: []cty.Value{
+					cty.NullVal(cty.String),
+				},
+				WantErr: "value is null but was assumed to be base64",
+			},
+		},
+		"base64url": {
+			"known URL-safe base64": {
+				Args: []cty.Value{
+					cty.StringVal("aGk-Pz8="),
+				},
+				Want: cty.StringVal("aGk-Pz8="),
+			},
+			"known URL-safe base64 without padding": {
+				Args: []cty.Value{
+					cty.StringVal("aGk-Pz8"),
+				},
+				Want: cty.StringVal("aGk-Pz8"),
+			},
+			"known standard base64": {
+				Args: []cty.Value{
+					cty.StringVal("aGk+Pz8="),
+				},
+				WantErr: `value "aGk+Pz8=" is not valid URL-safe base64: illegal base64 data at input byte 3`,
+			},
+		},
+		"stringlength": {
+			"unknown string": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.NumberIntVal(40),
+				},
+				Want: cty.UnknownVal(cty.String).RefineNotNull(),
+			},
+			"known string of assumed length": {
+				Args: []cty.Value{
+					cty.StringVal("héllo"),
+					cty.NumberIntVal(5),
+				},
+				Want: cty.StringVal("héllo"),
+			},
+			"known string of other length": {
+				Args: []cty.Value{
+					cty.StringVal("hello"),
+					cty.NumberIntVal(40),
+				},
+				WantErr: `value "hello" has 5 characters but was assumed to have 40`,
+			},
+			"negative length": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.NumberIntVal(-1),
+				},
+				WantErr: "must be a whole number between 0 and 9223372036854775807",
+			},
+		},
+	})
+}
@@ -0,0 +1,169 @@
+package assume
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+var jsonstringFunc = &function.Spec{
+	Description: "Assume that the given string will contain JSON that conforms to the given type.",
+	Params: []function.Parameter{
+		{
+			Name:         "value",
+			Type:         cty.String,
+			Description:  "The value to make the assumption about.",
+			AllowNull:    true,
+			AllowUnknown: true,
+		},
+		jsonTypeParam,
+	},
+	Type: function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		if isUnknownTypeConstraint(args[1]) {
+			// We can't know what to assume until we know the type.
+			return cty.UnknownVal(retType), nil
+		}
+		ty, defaults, err := jsonTypeArg(args[1])
+		if err != nil {
+			return cty.UnknownVal(retType), function.NewArgError(1, err)
+		}
+		ret, _, err := assumeJSONString(args[0], ty, defaults)
+		return ret, err
+	},
+}
+
+var jsondecodedFunc = &function.Spec{
+	Description: "Assume that the given string will contain JSON that conforms to the given type, and return the result of decoding it.",
+	Params: []function.Parameter{
+		{
+			Name:         "value",
+			Type:         cty.String,
+			Description:  "The value to make the assumption about.",
+			AllowNull:    true,
+			AllowUnknown: true,
+		},
+		jsonTypeParam,
+	},
+	Type: func(args []cty.Value) (cty.Type, error) {
+		ty, _, err := jsonTypeArg(args[1])
+		if err != nil {
+			return cty.DynamicPseudoType, function.NewArgError(1, err)
+		}
+		if ty.HasDynamicTypes() {
+			// As with typed, the final type will depend on the decoded
+			// value.
+			return cty.DynamicPseudoType, nil
+		}
+		return ty.WithoutOptionalAttributesDeep(), nil
+	},
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		if isUnknownTypeConstraint(args[1]) {
+			// We can't know what to assume until we know the type.
+			return cty.UnknownVal(retType), nil
+		}
+		ty, defaults, err := jsonTypeArg(args[1])
+		if err != nil {
+			return cty.UnknownVal(retType), function.NewArgError(1, err)
+		}
+		_, ret, err := assumeJSONString(args[0], ty, defaults)
+		if err != nil {
+			return cty.UnknownVal(retType), err
+		}
+		if !ret.IsKnown() {
+			return ret, nil
+		}
+		// The decoded value has the given type, but the return type might
+		// be more specific if the given type has dynamic parts.
+		return convert.Convert(ret, retType)
+	},
+}
+
+// jsonTypeParam is the parameter shared by jsonstring and jsondecoded to
+// describe the type that the JSON must conform to.
+var jsonTypeParam = function.Parameter{
+	Name:             "type",
+	Type:             cty.DynamicPseudoType,
+	Description:      "A type constraint using the same syntax as Terraform's input variable type constraints, or an example value of the expected type.",
+	AllowUnknown:     true,
+	AllowDynamicType: true,
+}
+
+// jsonTypeArg returns the type described by the type argument of jsonstring
+// or jsondecoded, which is either a type constraint string or an example
+// value whose type is the type constraint.
+//
+// An unknown type constraint string describes cty.DynamicPseudoType, but
+// callers should check for that using isUnknownTypeConstraint and return an
+// unknown result instead of making any assumptions.
+func jsonTypeArg(arg cty.Value) (cty.Type, *typeexpr.Defaults, error) {
+	if arg.Type() != cty.String {
+		return arg.Type(), nil, nil
+	}
+	if !arg.IsKnown() {
+		return cty.DynamicPseudoType, nil, nil
+	}
+	if arg.IsNull() {
+		return cty.DynamicPseudoType, nil, fmt.Errorf("must not be null")
+	}
+	return parseTypeConstraint(arg.AsString())
+}
+
+// isUnknownTypeConstraint returns true if the given type argument of
+// jsonstring or jsondecoded is a type constraint string that isn't known yet.
+// An unknown example value still has a known type, so it doesn't count.
+func isUnknownTypeConstraint(arg cty.Value) bool {
+	return arg.Type() == cty.String && !arg.IsKnown()
+}
+
+// assumeJSONString implements both jsonstring and jsondecoded, returning
+// the string value that jsonstring returns and the decoded value that
+// jsondecoded returns.
+func assumeJSONString(v cty.Value, ty cty.Type, defaults *typeexpr.Defaults) (cty.Value, cty.Value, error) {
+	retTy := ty.WithoutOptionalAttributesDeep()
+
+	if v.IsKnown() {
+		if v.IsNull() {
+			return cty.UnknownVal(cty.String), cty.UnknownVal(retTy), function.NewArgErrorf(0, "value is null but was assumed to contain JSON")
+		}
+		decoded, err := decodeJSONAs(v.AsString(), ty, defaults)
+		if err != nil {
+			return cty.UnknownVal(cty.String), cty.UnknownVal(retTy), function.NewArgError(0, err)
+		}
+		return v, decoded, nil
+	}
+
+	// We can't promise a prefix, because JSON can start with whitespace,
+	// and we can't promise that the decoded value isn't null, because
+	// the JSON might be "null".
+	ret, err := refineStringPrefix(v, "", true, "implied by the assumed type "+typeexpr.TypeString(ty))
+	if err != nil {
+		return cty.UnknownVal(cty.String), cty.UnknownVal(retTy), err
+	}
+	return ret, cty.UnknownVal(retTy), nil
+}
+
+// decodeJSONAs decodes the given JSON string and converts the result to the
+// given type, using the same rules as Terraform's jsondecode function
+// followed by conversion to an input variable's type constraint.
+func decodeJSONAs(src string, ty cty.Type, defaults *typeexpr.Defaults) (cty.Value, error) {
+	v, err := decodeJSONForComparison(src)
+	if err != nil {
+		if redaction != redactNone {
+			// JSON syntax errors can include parts of the input.
+			return cty.NilVal, fmt.Errorf("value is not valid JSON")
+		}
+		return cty.NilVal, fmt.Errorf("value is not valid JSON: %s", err)
+	}
+	if defaults != nil {
+		v = defaults.Apply(v)
+	}
+	v, err = convert.Convert(v, ty)
+	if err != nil {
+		return cty.NilVal, fmt.Errorf("value contains JSON that does not conform to type %s: %s", typeexpr.TypeString(ty), err)
+	}
+	return v, nil
+}
@@ -0,0 +1,189 @@
+package assume
+
+import (
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestJSONFuncs(t *testing.T) {
+	policyType := cty.StringVal(`object({Version=string, Statement=list(object({Effect=string}))})`)
+	policyJSON := cty.StringVal(`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"s3:GetObject"}]}`)
+
+	testProviderFuncs(t, funcTests{
+		"jsonstring": {
+			"unknown string of object type": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					policyType,
+				},
+				Want: cty.UnknownVal(cty.String).Refine().
+					NotNull().
+					NewValue(),
+			},
+			"unknown string of list type": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.StringVal("list(string)"),
+				},
+				Want: cty.UnknownVal(cty.String).Refine().
+					NotNull().
+					NewValue(),
+			},
+			"unknown string of primitive type": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.StringVal("number"),
+				},
+				Want: cty.UnknownVal(cty.String).Refine().
+					NotNull().
+					NewValue(),
+			},
+			"unknown string with example value": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.ObjectVal(map[string]cty.Value{
+						"name": cty.StringVal("example"),
+					}),
+				},
+				Want: cty.UnknownVal(cty.String).Refine().
+					NotNull().
+					NewValue(),
+			},
+			"unknown string with unknown type constraint": {
+				Args: []cty.Value{
+					cty.StringVal(`{"a":"b"}`),
+					cty.UnknownVal(cty.String),
+				},
+				Want: cty.UnknownVal(cty.String),
+			},
+			"known JSON that conforms": {
+				Args: []cty.Value{
+					policyJSON,
+					policyType,
+				},
+				Want: policyJSON,
+			},
+			"known JSON that conforms to example": {
+				Args: []cty.Value{
+					cty.StringVal(`{"name":"a","port":80}`),
+					cty.ObjectVal(map[string]cty.Value{
+						"name": cty.StringVal("example"),
+					}),
+				},
+				Want: cty.StringVal(`{"name":"a","port":80}`),
+			},
+			"known JSON that doesn't conform": {
+				Args: []cty.Value{
+					cty.StringVal(`{"Version":"2012-10-17"}`),
+					policyType,
+				},
+				WantErr: `value contains JSON that does not conform to type object({Statement=list(object({Effect=string})),Version=string}): attribute "Statement" is required`,
+			},
+			"known JSON with leading whitespace": {
+				Args: []cty.Value{
+					cty.StringVal("\n [\"a\"]"),
+					cty.StringVal("list(string)"),
+				},
+				Want: cty.StringVal("\n [\"a\"]"),
+			},
+			"known string that isn't JSON": {
+				Args: []cty.Value{
+					cty.StringVal(`{`),
+					cty.StringVal("map(string)"),
+				},
+				WantErr: `value is not valid JSON: EOF`,
+			},
+			"null string": {
+				Args: []cty.Value{
+					cty.NullVal(cty.String),
+					cty.StringVal("map(string)"),
+				},
+				WantErr: "value is null but was assumed to contain JSON",
+			},
+			"invalid type constraint": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.StringVal("lisst(string)"),
+				},
+				WantErr: `invalid type constraint: Keyword "lisst" is not a valid type constructor.`,
+			},
+		},
+		"jsondecoded": {
+			"unknown string of object type": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					policyType,
+				},
+				Want: cty.UnknownVal(cty.Object(map[string]cty.Type{
+					"Version": cty.String,
+					"Statement": cty.List(cty.Object(map[string]cty.Type{
+						"Effect": cty.String,
+					})),
+				})),
+			},
+			"unknown string of primitive type": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.StringVal("bool"),
+				},
+				Want: cty.UnknownVal(cty.Bool),
+			},
+			"unknown string of dynamic type": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.StringVal("any"),
+				},
+				Want: cty.DynamicVal,
+			},
+			"unknown type constraint": {
+				Args: []cty.Value{
+					cty.StringVal(`{"a":"b"}`),
+					cty.UnknownVal(cty.String),
+				},
+				Want: cty.DynamicVal,
+			},
+			"known JSON that conforms": {
+				Args: []cty.Value{
+					policyJSON,
+					policyType,
+				},
+				Want: cty.ObjectVal(map[string]cty.Value{
+					"Version": cty.StringVal("2012-10-17"),
+					"Statement": cty.ListVal([]cty.Value{
+						cty.ObjectVal(map[string]cty.Value{
+							"Effect": cty.StringVal("Allow"),
+						}),
+					}),
+				}),
+			},
+			"known JSON with optional attribute": {
+				Args: []cty.Value{
+					cty.StringVal(`{"name":"a"}`),
+					cty.StringVal(`object({name=string, replicas=optional(number, 1)})`),
+				},
+				Want: cty.ObjectVal(map[string]cty.Value{
+					"name":     cty.StringVal("a"),
+					"replicas": cty.NumberIntVal(1),
+				}),
+			},
+			"known JSON of dynamic type": {
+				Args: []cty.Value{
+					cty.StringVal(`[1, "a"]`),
+					cty.StringVal("any"),
+				},
+				Want: cty.TupleVal([]cty.Value{
+					cty.NumberIntVal(1),
+					cty.StringVal("a"),
+				}),
+			},
+			"known JSON that doesn't conform": {
+				Args: []cty.Value{
+					cty.StringVal(`["a"]`),
+					cty.StringVal("map(string)"),
+				},
+				WantErr: `value contains JSON that does not conform to type map(string): map of string required`,
+			},
+		},
+	})
+}
@@ -0,0 +1,556 @@
+package assume
+
+import (
+	"fmt"
+	"math/big"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// urlComponentNames are the names of the components accepted by urlassume.
+var urlComponentNames = []string{
+	"scheme",
+	"host",
+	"host_suffix",
+	"port",
+	"path_prefix",
+}
+
+var urlassumeFunc = &function.Spec{
+	Description: "Assume that the given string will be a URL with the given components.",
+	Params: []function.Parameter{
+		{
+			Name:         "value",
+			Type:         cty.String,
+			Description:  "The value to make the assumption about.",
+			AllowNull:    true,
+			AllowUnknown: true,
+		},
+		{
+			Name:             "components",
+			Type:             cty.DynamicPseudoType,
+			Description:      "An object describing some or all of scheme, host, host_suffix, port, and path_prefix.",
+			AllowUnknown:     true,
+			AllowDynamicType: true,
+		},
+	},
+	Type: function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		v := args[0]
+		comps, err := stringComponents(args[1], urlComponentNames)
+		if err != nil {
+			return cty.UnknownVal(retType), function.NewArgError(1, err)
+		}
+		if err := checkURLComponents(comps); err != nil {
+			return cty.UnknownVal(retType), function.NewArgError(1, err)
+		}
+
+		if v.IsKnown() {
+			if v.IsNull() {
+				return cty.UnknownVal(retType), function.NewArgErrorf(0, "value is null but was assumed to be a URL")
+			}
+			s := v.AsString()
+			u, err := url.Parse(s)
+			if err != nil || u.Scheme == "" {
+				return cty.UnknownVal(retType), function.NewArgErrorf(0, "value %s is not an absolute URL", displayActualValue(v))
+			}
+			if diffs := urlDiffs(u, comps); len(diffs) != 0 {
+				return cty.UnknownVal(retType), function.NewArgErrorf(0, "value %s does not match the assumed URL:\n  %s", displayActualValue(v), strings.Join(diffs, "\n  "))
+			}
+			// The components might all match while the URL is still
+			// written differently than the prefix we'd have promised during
+			// planning, such as if it includes a username or writes the
+			// scheme or host in a different case.
+			if prefix := renderURLPrefix(comps); !strings.HasPrefix(s, prefix) {
+				return cty.UnknownVal(retType), function.NewArgErrorf(0, "value %s does not start with %q, implied by the assumed URL components", displayActualValue(v), prefix)
+			}
+			return v, nil
+		}
+
+		prefix := renderURLPrefix(comps)
+		return refineStringPrefix(v, prefix, true, "implied by the assumed URL components")
+	},
+}
+
+// checkURLComponents returns an error if any of the known URL components
+// could not possibly appear in a URL in the way that urlassume expects.
+func checkURLComponents(comps map[string]cty.Value) error {
+	if val, ok := comps["scheme"]; ok && val.IsKnown() {
+		s := val.AsString()
+		if s == "" || strings.ContainsAny(s, ":/") {
+			return fmt.Errorf(`component "scheme" must be a scheme without its separator, like "https"`)
+		}
+	}
+	for _, name := range []string{"host", "host_suffix"} {
+		if val, ok := comps[name]; ok && val.IsKnown() && strings.ContainsAny(val.AsString(), "/?#") {
+			return fmt.Errorf("component %q must be a host name without a path", name)
+		}
+	}
+	if val, ok := comps["port"]; ok && val.IsKnown() {
+		if strings.Trim(val.AsString(), "0123456789") != "" {
+			return fmt.Errorf(`component "port" must be a port number, or an empty string if the URL has no port`)
+		}
+	}
+	if val, ok := comps["path_prefix"]; ok && val.IsKnown() && !strings.HasPrefix(val.AsString(), "/") {
+		return fmt.Errorf(`component "path_prefix" must start with a slash`)
+	}
+	return nil
+}
+
+// urlDiffs returns descriptions of each of the ways that the given URL
+// differs from the assumed components.
+//
+// The scheme and host are compared case-insensitively, because URLs treat
+// them that way.
+func urlDiffs(u *url.URL, comps map[string]cty.Value) []string {
+	actual := map[string]string{
+		"scheme":      u.Scheme,
+		"host":        u.Hostname(),
+		"host_suffix": u.Hostname(),
+		"port":        u.Port(),
+		"path_prefix": u.EscapedPath(),
+	}
+	var diffs []string
+	for _, name := range urlComponentNames {
+		want, ok := comps[name]
+		if !ok {
+			continue
+		}
+		got := actual[name]
+		label, _, _ := strings.Cut(name, "_")
+		if !want.IsKnown() {
+			if got == "" && (name == "host" || name == "port") {
+				diffs = append(diffs, fmt.Sprintf("%s is missing but was assumed to be present", label))
+			}
+			continue
+		}
+		ws := want.AsString()
+		switch name {
+		case "host_suffix":
+			if !hasSuffixFold(got, ws) {
+				diffs = append(diffs, fmt.Sprintf("host is %s but was assumed to end with %q", displayActualString(got), ws))
+			}
+		case "path_prefix":
+			if !strings.HasPrefix(got, ws) {
+				diffs = append(diffs, fmt.Sprintf("path is %s but was assumed to start with %q", displayActualString(got), ws))
+			}
+		default:
+			switch {
+			case got == ws:
+			case (name == "scheme" || name == "host") && strings.EqualFold(got, ws):
+			case got == "":
+				diffs = append(diffs, fmt.Sprintf("%s is missing but was assumed to be %q", label, ws))
+			case ws == "":
+				diffs = append(diffs, fmt.Sprintf("%s is %s but was assumed to be absent", label, displayActualString(got)))
+			default:
+				diffs = append(diffs, fmt.Sprintf("%s is %s but was assumed to be %q", label, displayActualString(got), ws))
+			}
+		}
+	}
+	return diffs
+}
+
+// renderURLPrefix returns the longest prefix of a URL that the given
+// components imply.
+//
+// The "//" after the scheme is included only if the host is present, since
+// URLs like "mailto:" URLs have no host. The path prefix is included only if
+// the port is known too, since otherwise we can't know whether a port
+// follows the host.
+//
+// The result uses the scheme and host exactly as given, so a known URL must
+// write them in the same case to match it.
+func renderURLPrefix(comps map[string]cty.Value) string {
+	known := func(name string) (string, bool) {
+		val, ok := comps[name]
+		if !ok || !val.IsKnown() {
+			return "", false
+		}
+		return val.AsString(), true
+	}
+
+	var buf strings.Builder
+	scheme, ok := known("scheme")
+	if !ok {
+		return ""
+	}
+	buf.WriteString(scheme)
+	buf.WriteByte(':')
+	if _, ok := comps["host"]; !ok {
+		return buf.String()
+	}
+	buf.WriteString("//")
+	host, ok := known("host")
+	if !ok {
+		return buf.String()
+	}
+	buf.WriteString(host)
+	port, ok := known("port")
+	if !ok {
+		return buf.String()
+	}
+	if port != "" {
+		buf.WriteByte(':')
+		buf.WriteString(port)
+	}
+	if path, ok := known("path_prefix"); ok {
+		buf.WriteString(path)
+	}
+	return buf.String()
+}
+
+var ipincidrFunc = &function.Spec{
+	Description: "Assume that the given string will be an IP address within the given CIDR range.",
+	Params: []function.Parameter{
+		{
+			Name:         "value",
+			Type:         cty.String,
+			Description:  "The value to make the assumption about.",
+			AllowNull:    true,
+			AllowUnknown: true,
+		},
+		{
+			Name:        "range",
+			Type:        cty.String,
+			Description: "The range that the address will belong to, in CIDR notation.",
+		},
+	},
+	Type: function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		v := args[0]
+		rng, err := parseAssumedRange(args[1].AsString())
+		if err != nil {
+			return cty.UnknownVal(retType), function.NewArgError(1, err)
+		}
+
+		if v.IsKnown() {
+			if v.IsNull() {
+				return cty.UnknownVal(retType), function.NewArgErrorf(0, "value is null but was assumed to be an IP address in %s", rng)
+			}
+			addr, err := netip.ParseAddr(v.AsString())
+			if err != nil {
+				return cty.UnknownVal(retType), function.NewArgErrorf(0, "value %s is not an IP address", displayActualValue(v))
+			}
+			if fam := ipFamily(addr); fam != ipFamily(rng.Addr()) {
+				return cty.UnknownVal(retType), function.NewArgErrorf(0, "value %s is an %s address but was assumed to be in the %s range %s", displayActualValue(v), fam, ipFamily(rng.Addr()), rng)
+			}
+			if !rng.Contains(addr) {
+				return cty.UnknownVal(retType), function.NewArgErrorf(0, "value %s is not in the assumed range %s", displayActualValue(v), rng)
+			}
+			return v, nil
+		}
+		return refineIPRangePrefix(v, rng)
+	},
+}
+
+var cidrwithinFunc = &function.Spec{
+	Description: "Assume that the given string will be a CIDR prefix within the given CIDR range, with a prefix length in the given bounds.",
+	Params: []function.Parameter{
+		{
+			Name:         "value",
+			Type:         cty.String,
+			Description:  "The value to make the assumption about.",
+			AllowNull:    true,
+			AllowUnknown: true,
+		},
+		{
+			Name:        "range",
+			Type:        cty.String,
+			Description: "The range that the prefix will belong to, in CIDR notation.",
+		},
+		{
+			Name:        "min_prefix_len",
+			Type:        cty.Number,
+			Description: "The minimum possible prefix length.",
+		},
+		{
+			Name:        "max_prefix_len",
+			Type:        cty.Number,
+			Description: "The maximum possible prefix length.",
+		},
+	},
+	Type: function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		v := args[0]
+		rng, err := parseAssumedRange(args[1].AsString())
+		if err != nil {
+			return cty.UnknownVal(retType), function.NewArgError(1, err)
+		}
+		bitLen := rng.Addr().BitLen()
+		var bounds [2]int
+		for i, arg := range args[2:] {
+			n, acc := arg.AsBigFloat().Int64()
+			if acc != big.Exact || n < 0 || n > int64(bitLen) {
+				return cty.UnknownVal(retType), function.NewArgErrorf(i+2, "must be a whole number between 0 and %d", bitLen)
+			}
+			bounds[i] = int(n)
+		}
+		minLen, maxLen := bounds[0], bounds[1]
+		if minLen > maxLen {
+			return cty.UnknownVal(retType), function.NewArgErrorf(3, "must not be less than min_prefix_len")
+		}
+		if maxLen < rng.Bits() {
+			return cty.UnknownVal(retType), function.NewArgErrorf(3, "must not be less than the prefix length of the range, %d", rng.Bits())
+		}
+
+		if v.IsKnown() {
+			if v.IsNull() {
+				return cty.UnknownVal(retType), function.NewArgErrorf(0, "value is null but was assumed to be a CIDR prefix within %s", rng)
+			}
+			p, err := netip.ParsePrefix(v.AsString())
+			if err != nil {
+				return cty.UnknownVal(retType), function.NewArgErrorf(0, "value %s is not a CIDR prefix", displayActualValue(v))
+			}
+			if fam := ipFamily(p.Addr()); fam != ipFamily(rng.Addr()) {
+				return cty.UnknownVal(retType), function.NewArgErrorf(0, "value %s is an %s prefix but was assumed to be within the %s range %s", displayActualValue(v), fam, ipFamily(rng.Addr()), rng)
+			}
+			if p.Bits() < rng.Bits() || !rng.Contains(p.Addr()) {
+				return cty.UnknownVal(retType), function.NewArgErrorf(0, "value %s is not within the assumed range %s", displayActualValue(v), rng)
+			}
+			if p.Bits() < minLen || p.Bits() > maxLen {
+				return cty.UnknownVal(retType), function.NewArgErrorf(0, "value %s has prefix length %d but was assumed to have a prefix length between %d and %d", displayActualValue(v), p.Bits(), minLen, maxLen)
+			}
+			return v, nil
+		}
+		return refineIPRangePrefix(v, rng)
+	},
+}
+
+// parseAssumedRange parses a CIDR range given as an argument to ipincidr or
+// cidrwithin, returning an error suitable for reporting against that
+// argument if it isn't valid.
+func parseAssumedRange(s string) (netip.Prefix, error) {
+	rng, err := netip.ParsePrefix(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("must be a range in CIDR notation, like \"10.20.0.0/16\"")
+	}
+	if rng != rng.Masked() {
+		return netip.Prefix{}, fmt.Errorf("must not have any bits set after the prefix; did you mean %q?", rng.Masked().String())
+	}
+	return rng, nil
+}
+
+// ipRangeStringPrefix returns the prefix that the string representation of
+// all addresses and CIDR prefixes within the given range must have.
+//
+// For IPv4 this is the octets covered by the prefix length, and so a range
+// like 10.20.0.0/20 implies the prefix "10.20.". IPv6 addresses have many
+// equivalent string representations, varying in letter case, leading zeros
+// and which zero groups are abbreviated, so IPv6 ranges imply no prefix.
+func ipRangeStringPrefix(rng netip.Prefix) string {
+	if !rng.Addr().Is4() {
+		return ""
+	}
+	octets := rng.Addr().As4()
+	var parts []string
+	for i := 0; i < rng.Bits()/8; i++ {
+		parts = append(parts, strconv.Itoa(int(octets[i])))
+	}
+	switch len(parts) {
+	case 0:
+		return ""
+	case 4:
+		return strings.Join(parts, ".")
+	default:
+		return strings.Join(parts, ".") + "."
+	}
+}
+
+// refineIPRangePrefix returns the given unknown value annotated as not null
+// and as having the prefix implied by the given range, if any.
+func refineIPRangePrefix(v cty.Value, rng netip.Prefix) (cty.Value, error) {
+	return refineStringPrefix(v, ipRangeStringPrefix(rng), true, fmt.Sprintf("implied by the assumed range %s", rng))
+}
+
+// ipFamily returns the name of the address family of the given address.
+func ipFamily(addr netip.Addr) string {
+	if addr.Is4() {
+		return "IPv4"
+	}
+	return "IPv6"
+}
+
+var hostnameFunc = makeStringFormatFunc(
+	"Assume that the given string will be a valid hostname, optionally under the given domain.",
+	"a hostname",
+	func(args []cty.Value) error {
+		if args[0].IsNull() {
+			return nil
+		}
+		if problem := hostnameProblem(args[0].AsString()); problem != "" {
+			return function.NewArgErrorf(0, "must be a valid domain name, but %s", problem)
+		}
+		return nil
+	},
+	func(s string, args []cty.Value) string {
+		if problem := hostnameProblem(s); problem != "" {
+			return "is not a valid hostname because " + problem
+		}
+		if args[0].IsNull() {
+			return ""
+		}
+		name := strings.TrimSuffix(s, ".")
+		domain := strings.TrimSuffix(args[0].AsString(), ".")
+		if !strings.EqualFold(name, domain) && !hasSuffixFold(name, "."+domain) {
+			return fmt.Sprintf("is not a hostname under the domain %q", domain)
+		}
+		return ""
+	},
+	function.Parameter{
+		Name:        "domain",
+		Type:        cty.String,
+		Description: "The domain that the hostname must be equal to or a subdomain of, or null to allow any domain.",
+		AllowNull:   true,
+	},
+)
+
+var dnslabelFunc = makeStringFormatFunc(
+	"Assume that the given string will be a valid RFC 1123 DNS label.",
+	"a DNS label",
+	nil,
+	func(s string, args []cty.Value) string {
+		if problem := dnsLabelProblem(s, true); problem != "" {
+			return "is not an RFC 1123 DNS label because it " + problem
+		}
+		return ""
+	},
+)
+
+var k8snameFunc = &function.Spec{
+	Description: "Assume that the given string will be a valid Kubernetes object name, optionally generated from the given generateName prefix.",
+	Params: []function.Parameter{
+		{
+			Name:         "value",
+			Type:         cty.String,
+			Description:  "The value to make the assumption about.",
+			AllowNull:    true,
+			AllowUnknown: true,
+		},
+		{
+			Name:        "generate_name",
+			Type:        cty.String,
+			Description: "The generateName prefix that Kubernetes used to generate the name, or null if the name was not generated.",
+			AllowNull:   true,
+		},
+	},
+	Type: function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		v := args[0]
+		var prefix string
+		if !args[1].IsNull() {
+			prefix = args[1].AsString()
+			if prefix == "" {
+				return cty.UnknownVal(retType), function.NewArgErrorf(1, "must not be empty; use null if the name was not generated")
+			}
+			// Kubernetes truncates long prefixes so that the generated
+			// name can't be longer than a DNS label.
+			if len(prefix) > k8sMaxGenerateNameLen {
+				prefix = prefix[:k8sMaxGenerateNameLen]
+			}
+		}
+
+		if v.IsKnown() {
+			if v.IsNull() {
+				return cty.UnknownVal(retType), function.NewArgErrorf(0, "value is null but was assumed to be a Kubernetes name")
+			}
+			s := v.AsString()
+			if args[1].IsNull() {
+				if problem := k8sNameProblem(s); problem != "" {
+					return cty.UnknownVal(retType), function.NewArgErrorf(0, "value %s is not a valid Kubernetes name because %s", displayActualValue(v), problem)
+				}
+				return v, nil
+			}
+			suffix, ok := strings.CutPrefix(s, prefix)
+			if !ok {
+				return cty.UnknownVal(retType), function.NewArgErrorf(0, "value %s does not start with the generate_name prefix %q", displayActualValue(v), prefix)
+			}
+			if len(suffix) != k8sGeneratedSuffixLen || strings.Trim(suffix, "abcdefghijklmnopqrstuvwxyz0123456789") != "" {
+				return cty.UnknownVal(retType), function.NewArgErrorf(0, "value %s does not end with the %d random lowercase letters and digits that Kubernetes appends to the generate_name prefix", displayActualValue(v), k8sGeneratedSuffixLen)
+			}
+			if problem := k8sNameProblem(s); problem != "" {
+				return cty.UnknownVal(retType), function.NewArgErrorf(0, "value %s is not a valid Kubernetes name because %s", displayActualValue(v), problem)
+			}
+			return v, nil
+		}
+
+		return refineStringPrefix(v, prefix, true, "from the generate_name prefix")
+	},
+}
+
+const (
+	// k8sGeneratedSuffixLen is the number of random characters that
+	// Kubernetes appends to a generateName prefix.
+	k8sGeneratedSuffixLen = 5
+
+	// k8sMaxGenerateNameLen is the length that Kubernetes truncates a
+	// generateName prefix to, so that the generated name fits in a DNS label.
+	k8sMaxGenerateNameLen = 63 - k8sGeneratedSuffixLen
+)
+
+// hostnameProblem returns a description of why the given string is not a
+// valid hostname under the rules of RFC 1123, or an empty string if it is
+// valid. A single trailing dot is allowed, as in a fully-qualified name.
+func hostnameProblem(s string) string {
+	name := strings.TrimSuffix(s, ".")
+	if len(name) > 253 {
+		return "it is longer than 253 characters"
+	}
+	for i, label := range strings.Split(name, ".") {
+		if problem := dnsLabelProblem(label, false); problem != "" {
+			return fmt.Sprintf("label %d %s", i+1, problem)
+		}
+	}
+	return ""
+}
+
+// k8sNameProblem returns a description of why the given string is not a
+// valid Kubernetes object name, or an empty string if it is valid.
+//
+// Most kinds of Kubernetes object require their names to be RFC 1123 DNS
+// subdomains, which are hostnames that use only lowercase letters.
+func k8sNameProblem(s string) string {
+	if len(s) > 253 {
+		return "it is longer than 253 characters"
+	}
+	labels := strings.Split(s, ".")
+	for i, label := range labels {
+		if problem := dnsLabelProblem(label, true); problem != "" {
+			if len(labels) == 1 {
+				return "it " + problem
+			}
+			return fmt.Sprintf("part %d %s", i+1, problem)
+		}
+	}
+	return ""
+}
+
+// dnsLabelProblem returns a description of why the given string is not a
+// valid RFC 1123 DNS label, or an empty string if it is valid. If lowercase
+// is true then uppercase letters are not allowed.
+func dnsLabelProblem(label string, lowercase bool) string {
+	switch {
+	case label == "":
+		return "is empty"
+	case len(label) > 63:
+		return "is longer than 63 characters"
+	}
+	for _, c := range label {
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '-':
+		case c >= 'A' && c <= 'Z' && !lowercase:
+		case lowercase:
+			return "contains a character other than lowercase letters, digits, and hyphens"
+		default:
+			return "contains a character other than letters, digits, and hyphens"
+		}
+	}
+	if strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+		return "starts or ends with a hyphen"
+	}
+	return ""
+}
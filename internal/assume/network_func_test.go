@@ -0,0 +1,609 @@
+package assume
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestNetworkFuncs(t *testing.T) {
+	testProviderFuncs(t, funcTests{
+		"urlassume": {
+			"scheme only": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.ObjectVal(map[string]cty.Value{
+						"scheme":      cty.StringVal("https"),
+						"host":        cty.UnknownVal(cty.String),
+						"host_suffix": cty.StringVal(".rds.amazonaws.com"),
+					}),
+				},
+				Want: cty.UnknownVal(cty.String).Refine().
+					NotNull().
+					StringPrefixFull("https://").
+					NewValue(),
+			},
+			"scheme without host": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.ObjectVal(map[string]cty.Value{
+						"scheme": cty.StringVal("mailto"),
+					}),
+				},
+				Want: cty.UnknownVal(cty.String).Refine().
+					NotNull().
+					StringPrefixFull("mailto:").
+					NewValue(),
+			},
+			"host known but port omitted": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.ObjectVal(map[string]cty.Value{
+						"scheme":      cty.StringVal("https"),
+						"host":        cty.StringVal("example.com"),
+						"path_prefix": cty.StringVal("/v1/"),
+					}),
+				},
+				// The path can't be included because a port might follow
+				// the host, but the host itself can be.
+				Want: cty.UnknownVal(cty.String).Refine().
+					NotNull().
+					StringPrefixFull("https://example.co").
+					NewValue(),
+			},
+			"host, port, and path known": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.ObjectVal(map[string]cty.Value{
+						"scheme":      cty.StringVal("https"),
+						"host":        cty.StringVal("example.com"),
+						"port":        cty.StringVal(""),
+						"path_prefix": cty.StringVal("/v1/"),
+					}),
+				},
+				Want: cty.UnknownVal(cty.String).Refine().
+					NotNull().
+					StringPrefixFull("https://example.com/v1/").
+					NewValue(),
+			},
+			"numeric port": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.ObjectVal(map[string]cty.Value{
+						"scheme": cty.StringVal("postgres"),
+						"host":   cty.StringVal("db.example.com"),
+						"port":   cty.NumberIntVal(5432),
+					}),
+				},
+				Want: cty.UnknownVal(cty.String).Refine().
+					NotNull().
+					StringPrefixFull("postgres://db.example.com:543").
+					NewValue(),
+			},
+			"components unknown": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.DynamicVal,
+				},
+				Want: cty.UnknownVal(cty.String).Refine().
+					NotNull().
+					NewValue(),
+			},
+			"unknown string with conflicting prefix": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String).Refine().StringPrefixFull("http://").NewValue(),
+					cty.ObjectVal(map[string]cty.Value{
+						"scheme": cty.StringVal("https"),
+						"host":   cty.UnknownVal(cty.String),
+					}),
+				},
+				WantErr: `value already known to start with "http://" which conflicts with assumed prefix "https://", implied by the assumed URL components`,
+			},
+			"known URL that matches": {
+				Args: []cty.Value{
+					cty.StringVal("https://example.abc123.us-west-2.rds.amazonaws.com:5432/db"),
+					cty.ObjectVal(map[string]cty.Value{
+						"scheme":      cty.StringVal("https"),
+						"host":        cty.UnknownVal(cty.String),
+						"host_suffix": cty.StringVal(".rds.amazonaws.com"),
+						"port":        cty.UnknownVal(cty.String),
+						"path_prefix": cty.StringVal("/"),
+					}),
+				},
+				Want: cty.StringVal("https://example.abc123.us-west-2.rds.amazonaws.com:5432/db"),
+			},
+			"known URL with differing components": {
+				Args: []cty.Value{
+					cty.StringVal("http://example.com:8080/v2/things"),
+					cty.ObjectVal(map[string]cty.Value{
+						"scheme":      cty.StringVal("https"),
+						"host_suffix": cty.StringVal(".example.net"),
+						"port":        cty.StringVal(""),
+						"path_prefix": cty.StringVal("/v1/"),
+					}),
+				},
+				WantErr: "value \"http://example.com:8080/v2/things\" does not match the assumed URL:\n  scheme is \"http\" but was assumed to be \"https\"\n  host is \"example.com\" but was assumed to end with \".example.net\"\n  port is \"8080\" but was assumed to be absent\n  path is \"/v2/things\" but was assumed to start with \"/v1/\"",
+			},
+			"known URL with scheme and host in different case": {
+				Args: []cty.Value{
+					cty.StringVal("HTTPS://Example.COM/v1/"),
+					cty.ObjectVal(map[string]cty.Value{
+						"scheme": cty.StringVal("https"),
+						"host":   cty.StringVal("example.com"),
+						"port":   cty.StringVal(""),
+					}),
+				},
+				WantErr: `value "HTTPS://Example.COM/v1/" does not start with "https://example.com", implied by the assumed URL components`,
+			},
+			"known URL with unknown scheme and host in different case": {
+				Args: []cty.Value{
+					cty.StringVal("HTTPS://Example.COM/v1/"),
+					cty.ObjectVal(map[string]cty.Value{
+						"scheme": cty.UnknownVal(cty.String),
+						"host":   cty.StringVal("example.com"),
+					}),
+				},
+				Want: cty.StringVal("HTTPS://Example.COM/v1/"),
+			},
+			"known URL with host suffix in different case": {
+				Args: []cty.Value{
+					cty.StringVal("https://db.Example.NET/"),
+					cty.ObjectVal(map[string]cty.Value{
+						"host_suffix": cty.StringVal(".example.net"),
+					}),
+				},
+				Want: cty.StringVal("https://db.Example.NET/"),
+			},
+			"known URL with missing components": {
+				Args: []cty.Value{
+					cty.StringVal("file:///tmp/example"),
+					cty.ObjectVal(map[string]cty.Value{
+						"host": cty.UnknownVal(cty.String),
+						"port": cty.StringVal("443"),
+					}),
+				},
+				WantErr: "value \"file:///tmp/example\" does not match the assumed URL:\n  host is missing but was assumed to be present\n  port is missing but was assumed to be \"443\"",
+			},
+			"known URL with username": {
+				Args: []cty.Value{
+					cty.StringVal("https://admin@example.com/"),
+					cty.ObjectVal(map[string]cty.Value{
+						"scheme": cty.StringVal("https"),
+						"host":   cty.StringVal("example.com"),
+						"port":   cty.StringVal(""),
+					}),
+				},
+				WantErr: `value "https://admin@example.com/" does not start with "https://example.com", implied by the assumed URL components`,
+			},
+			"known string that isn't a URL": {
+				Args: []cty.Value{
+					cty.StringVal("example.com"),
+					cty.EmptyObjectVal,
+				},
+				WantErr: `value "example.com" is not an absolute URL`,
+			},
+			"null string": {
+				Args: []cty.Value{
+					cty.NullVal(cty.String),
+					cty.EmptyObjectVal,
+				},
+				WantErr: "value is null but was assumed to be a URL",
+			},
+			"scheme with separator": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.ObjectVal(map[string]cty.Value{
+						"scheme": cty.StringVal("https://"),
+					}),
+				},
+				WantErr: `component "scheme" must be a scheme without its separator, like "https"`,
+			},
+			"relative path prefix": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.ObjectVal(map[string]cty.Value{
+						"path_prefix": cty.StringVal("v1/"),
+					}),
+				},
+				WantErr: `component "path_prefix" must start with a slash`,
+			},
+		},
+		"ipincidr": {
+			"octet boundary": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.StringVal("10.20.0.0/16"),
+				},
+				Want: cty.UnknownVal(cty.String).Refine().
+					NotNull().
+					StringPrefixFull("10.20.").
+					NewValue(),
+			},
+			"between octet boundaries": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.StringVal("10.20.16.0/20"),
+				},
+				Want: cty.UnknownVal(cty.String).Refine().
+					NotNull().
+					StringPrefixFull("10.20.").
+					NewValue(),
+			},
+			"short prefix": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.StringVal("0.0.0.0/0"),
+				},
+				Want: cty.UnknownVal(cty.String).Refine().
+					NotNull().
+					NewValue(),
+			},
+			"ipv6": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.StringVal("2001:db8:a::/48"),
+				},
+				// IPv6 addresses have many equivalent representations,
+				// so an IPv6 range implies no prefix.
+				Want: cty.UnknownVal(cty.String).Refine().
+					NotNull().
+					NewValue(),
+			},
+			"unknown string with conflicting prefix": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String).Refine().StringPrefixFull("172.").NewValue(),
+					cty.StringVal("10.20.0.0/16"),
+				},
+				WantErr: `value already known to start with "172." which conflicts with assumed prefix "10.20.", implied by the assumed range 10.20.0.0/16`,
+			},
+			"known address in range": {
+				Args: []cty.Value{
+					cty.StringVal("10.20.30.40"),
+					cty.StringVal("10.20.0.0/16"),
+				},
+				Want: cty.StringVal("10.20.30.40"),
+			},
+			"known address not in range": {
+				Args: []cty.Value{
+					cty.StringVal("10.21.30.40"),
+					cty.StringVal("10.20.0.0/16"),
+				},
+				WantErr: `value "10.21.30.40" is not in the assumed range 10.20.0.0/16`,
+			},
+			"known address in other family": {
+				Args: []cty.Value{
+					cty.StringVal("2001:db8::1"),
+					cty.StringVal("10.20.0.0/16"),
+				},
+				WantErr: `value "2001:db8::1" is an IPv6 address but was assumed to be in the IPv4 range 10.20.0.0/16`,
+			},
+			"known address in uppercase": {
+				Args: []cty.Value{
+					cty.StringVal("2001:DB8::1"),
+					cty.StringVal("2001:db8::/32"),
+				},
+				Want: cty.StringVal("2001:DB8::1"),
+			},
+			"known address with leading zeros": {
+				Args: []cty.Value{
+					cty.StringVal("2001:0db8:000a::1"),
+					cty.StringVal("2001:db8:a::/48"),
+				},
+				Want: cty.StringVal("2001:0db8:000a::1"),
+			},
+			"known string that isn't an address": {
+				Args: []cty.Value{
+					cty.StringVal("10.20.0.0/24"),
+					cty.StringVal("10.20.0.0/16"),
+				},
+				WantErr: `value "10.20.0.0/24" is not an IP address`,
+			},
+			"null string": {
+				Args: []cty.Value{
+					cty.NullVal(cty.String),
+					cty.StringVal("10.20.0.0/16"),
+				},
+				WantErr: "value is null but was assumed to be an IP address in 10.20.0.0/16",
+			},
+			"invalid range": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.StringVal("10.20.0.0"),
+				},
+				WantErr: `must be a range in CIDR notation, like "10.20.0.0/16"`,
+			},
+			"range with host bits": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.StringVal("10.20.1.0/16"),
+				},
+				WantErr: `must not have any bits set after the prefix; did you mean "10.20.0.0/16"?`,
+			},
+		},
+		"cidrwithin": {
+			"unknown string": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.StringVal("10.20.0.0/16"),
+					cty.NumberIntVal(20),
+					cty.NumberIntVal(24),
+				},
+				Want: cty.UnknownVal(cty.String).Refine().
+					NotNull().
+					StringPrefixFull("10.20.").
+					NewValue(),
+			},
+			"known prefix in range": {
+				Args: []cty.Value{
+					cty.StringVal("10.20.4.0/24"),
+					cty.StringVal("10.20.0.0/16"),
+					cty.NumberIntVal(20),
+					cty.NumberIntVal(24),
+				},
+				Want: cty.StringVal("10.20.4.0/24"),
+			},
+			"known prefix not in range": {
+				Args: []cty.Value{
+					cty.StringVal("10.21.4.0/24"),
+					cty.StringVal("10.20.0.0/16"),
+					cty.NumberIntVal(20),
+					cty.NumberIntVal(24),
+				},
+				WantErr: `value "10.21.4.0/24" is not within the assumed range 10.20.0.0/16`,
+			},
+			"known prefix larger than range": {
+				Args: []cty.Value{
+					cty.StringVal("10.0.0.0/8"),
+					cty.StringVal("10.20.0.0/16"),
+					cty.NumberIntVal(0),
+					cty.NumberIntVal(32),
+				},
+				WantErr: `value "10.0.0.0/8" is not within the assumed range 10.20.0.0/16`,
+			},
+			"known prefix with wrong length": {
+				Args: []cty.Value{
+					cty.StringVal("10.20.4.0/28"),
+					cty.StringVal("10.20.0.0/16"),
+					cty.NumberIntVal(20),
+					cty.NumberIntVal(24),
+				},
+				WantErr: `value "10.20.4.0/28" has prefix length 28 but was assumed to have a prefix length between 20 and 24`,
+			},
+			"known prefix in other family": {
+				Args: []cty.Value{
+					cty.StringVal("2001:db8::/64"),
+					cty.StringVal("10.20.0.0/16"),
+					cty.NumberIntVal(20),
+					cty.NumberIntVal(24),
+				},
+				WantErr: `value "2001:db8::/64" is an IPv6 prefix but was assumed to be within the IPv4 range 10.20.0.0/16`,
+			},
+			"known string that isn't a prefix": {
+				Args: []cty.Value{
+					cty.StringVal("10.20.4.0"),
+					cty.StringVal("10.20.0.0/16"),
+					cty.NumberIntVal(20),
+					cty.NumberIntVal(24),
+				},
+				WantErr: `value "10.20.4.0" is not a CIDR prefix`,
+			},
+			"bounds out of order": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.StringVal("10.20.0.0/16"),
+					cty.NumberIntVal(24),
+					cty.NumberIntVal(20),
+				},
+				WantErr: "must not be less than min_prefix_len",
+			},
+			"bound too large": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.StringVal("10.20.0.0/16"),
+					cty.NumberIntVal(20),
+					cty.NumberIntVal(64),
+				},
+				WantErr: "must be a whole number between 0 and 32",
+			},
+			"max shorter than range": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.StringVal("10.20.0.0/16"),
+					cty.NumberIntVal(8),
+					cty.NumberIntVal(12),
+				},
+				WantErr: "must not be less than the prefix length of the range, 16",
+			},
+		},
+		"hostname": {
+			"unknown string": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.StringVal("elb.amazonaws.com"),
+				},
+				Want: cty.UnknownVal(cty.String).RefineNotNull(),
+			},
+			"known hostname under domain": {
+				Args: []cty.Value{
+					cty.StringVal("example-123.us-west-2.ELB.amazonaws.com"),
+					cty.StringVal("elb.amazonaws.com"),
+				},
+				Want: cty.StringVal("example-123.us-west-2.ELB.amazonaws.com"),
+			},
+			"known hostname equal to domain": {
+				Args: []cty.Value{
+					cty.StringVal("example.com."),
+					cty.StringVal("example.com"),
+				},
+				Want: cty.StringVal("example.com."),
+			},
+			"known hostname without domain": {
+				Args: []cty.Value{
+					cty.StringVal("localhost"),
+					cty.NullVal(cty.String),
+				},
+				Want: cty.StringVal("localhost"),
+			},
+			"known hostname under other domain": {
+				Args: []cty.Value{
+					cty.StringVal("www.notexample.com"),
+					cty.StringVal("example.com"),
+				},
+				WantErr: `value "www.notexample.com" is not a hostname under the domain "example.com"`,
+			},
+			"known hostname with empty label": {
+				Args: []cty.Value{
+					cty.StringVal("www..example.com"),
+					cty.NullVal(cty.String),
+				},
+				WantErr: `value "www..example.com" is not a valid hostname because label 2 is empty`,
+			},
+			"known hostname with invalid character": {
+				Args: []cty.Value{
+					cty.StringVal("my_host.example.com"),
+					cty.NullVal(cty.String),
+				},
+				WantErr: `value "my_host.example.com" is not a valid hostname because label 1 contains a character other than letters, digits, and hyphens`,
+			},
+			"known hostname with long label": {
+				Args: []cty.Value{
+					cty.StringVal(strings.Repeat("a", 64) + ".example.com"),
+					cty.NullVal(cty.String),
+				},
+				WantErr: `value "` + strings.Repeat("a", 64) + `.example.com" is not a valid hostname because label 1 is longer than 63 characters`,
+			},
+			"invalid domain": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.StringVal("-example.com"),
+				},
+				WantErr: "must be a valid domain name, but label 1 starts or ends with a hyphen",
+			},
+		},
+		"dnslabel": {
+			"unknown string": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+				},
+				Want: cty.UnknownVal(cty.String).RefineNotNull(),
+			},
+			"known label": {
+				Args: []cty.Value{
+					cty.StringVal("my-service-1"),
+				},
+				Want: cty.StringVal("my-service-1"),
+			},
+			"known label with uppercase letters": {
+				Args: []cty.Value{
+					cty.StringVal("My-Service"),
+				},
+				WantErr: `value "My-Service" is not an RFC 1123 DNS label because it contains a character other than lowercase letters, digits, and hyphens`,
+			},
+			"known label with trailing hyphen": {
+				Args: []cty.Value{
+					cty.StringVal("my-service-"),
+				},
+				WantErr: `value "my-service-" is not an RFC 1123 DNS label because it starts or ends with a hyphen`,
+			},
+			"null string": {
+				Args: []cty.Value{
+					cty.NullVal(cty.String),
+				},
+				WantErr: "value is null but was assumed to be a DNS label",
+			},
+		},
+		"k8sname": {
+			"unknown string": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.NullVal(cty.String),
+				},
+				Want: cty.UnknownVal(cty.String).RefineNotNull(),
+			},
+			"unknown string with generate_name": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.StringVal("job-"),
+				},
+				Want: cty.UnknownVal(cty.String).Refine().
+					NotNull().
+					StringPrefixFull("job-").
+					NewValue(),
+			},
+			"unknown string with long generate_name": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.StringVal(strings.Repeat("a", 60) + "-"),
+				},
+				Want: cty.UnknownVal(cty.String).Refine().
+					NotNull().
+					StringPrefix(strings.Repeat("a", 58)).
+					NewValue(),
+			},
+			"unknown string with conflicting prefix": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String).Refine().StringPrefixFull("cron-").NewValue(),
+					cty.StringVal("job-"),
+				},
+				WantErr: `value already known to start with "cron-" which conflicts with assumed prefix "job-", from the generate_name prefix`,
+			},
+			"known name": {
+				Args: []cty.Value{
+					cty.StringVal("example.v1"),
+					cty.NullVal(cty.String),
+				},
+				Want: cty.StringVal("example.v1"),
+			},
+			"known generated name": {
+				Args: []cty.Value{
+					cty.StringVal("job-x7k2p"),
+					cty.StringVal("job-"),
+				},
+				Want: cty.StringVal("job-x7k2p"),
+			},
+			"known name without generate_name prefix": {
+				Args: []cty.Value{
+					cty.StringVal("task-x7k2p"),
+					cty.StringVal("job-"),
+				},
+				WantErr: `value "task-x7k2p" does not start with the generate_name prefix "job-"`,
+			},
+			"known name without generated suffix": {
+				Args: []cty.Value{
+					cty.StringVal("job-1"),
+					cty.StringVal("job-"),
+				},
+				WantErr: `value "job-1" does not end with the 5 random lowercase letters and digits that Kubernetes appends to the generate_name prefix`,
+			},
+			"known name with uppercase letters": {
+				Args: []cty.Value{
+					cty.StringVal("Example"),
+					cty.NullVal(cty.String),
+				},
+				WantErr: `value "Example" is not a valid Kubernetes name because it contains a character other than lowercase letters, digits, and hyphens`,
+			},
+			"known name with empty part": {
+				Args: []cty.Value{
+					cty.StringVal("example..v1"),
+					cty.NullVal(cty.String),
+				},
+				WantErr: `value "example..v1" is not a valid Kubernetes name because part 2 is empty`,
+			},
+			"known generated name with invalid prefix": {
+				Args: []cty.Value{
+					cty.StringVal("Job-x7k2p"),
+					cty.StringVal("Job-"),
+				},
+				WantErr: `value "Job-x7k2p" is not a valid Kubernetes name because it contains a character other than lowercase letters, digits, and hyphens`,
+			},
+			"empty generate_name": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.StringVal(""),
+				},
+				WantErr: "must not be empty; use null if the name was not generated",
+			},
+		},
+	})
+}
@@ -0,0 +1,233 @@
+package assume
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+var notnulldeepFunc = &function.Spec{
+	Description: "Assume that the given value and all of the values nested inside it will never be null.",
+	Params: []function.Parameter{
+		{
+			Name:             "value",
+			Type:             cty.DynamicPseudoType,
+			Description:      "The value to make the assumption about.",
+			AllowNull:        true,
+			AllowUnknown:     true,
+			AllowDynamicType: true,
+		},
+	},
+	Type: func(args []cty.Value) (cty.Type, error) {
+		return args[0].Type(), nil
+	},
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		ret, err := refineNotNullDeep(args[0], nil)
+		if err != nil {
+			return cty.UnknownVal(retType), function.NewArgError(0, err)
+		}
+		return ret, nil
+	},
+}
+
+var notnullattrsFunc = &function.Spec{
+	Description: "Assume that the given object and the given attributes of it will never be null.",
+	Params: []function.Parameter{
+		{
+			Name:             "value",
+			Type:             cty.DynamicPseudoType,
+			Description:      "The object to make the assumption about.",
+			AllowNull:        true,
+			AllowUnknown:     true,
+			AllowDynamicType: true,
+		},
+		{
+			Name:        "attributes",
+			Type:        cty.Set(cty.String),
+			Description: "The names of the attributes to assume are not null.",
+		},
+	},
+	Type: func(args []cty.Value) (cty.Type, error) {
+		ty := args[0].Type()
+		if !ty.IsObjectType() && ty != cty.DynamicPseudoType {
+			return cty.DynamicPseudoType, function.NewArgErrorf(0, "must be an object")
+		}
+		return ty, nil
+	},
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		v := args[0]
+		if v == cty.DynamicVal {
+			// We can't say anything about the attributes of an object
+			// whose type we don't know yet.
+			return v, nil
+		}
+		ty := v.Type()
+
+		var names []string
+		for it := args[1].ElementIterator(); it.Next(); {
+			_, name := it.Element()
+			if name.IsNull() {
+				return cty.UnknownVal(retType), function.NewArgErrorf(1, "attribute names must not be null")
+			}
+			if !ty.HasAttribute(name.AsString()) {
+				return cty.UnknownVal(retType), function.NewArgErrorf(1, "object has no attribute %q", name.AsString())
+			}
+			names = append(names, name.AsString())
+		}
+
+		if v.IsNull() {
+			return cty.UnknownVal(retType), function.NewArgError(0, nullValueError(""))
+		}
+		var attrs map[string]cty.Value
+		if v.IsKnown() {
+			attrs = v.AsValueMap()
+		} else {
+			// An object with non-null attributes can't itself be null, so
+			// we can expand an unknown object into a known object whose
+			// attributes are all unknown.
+			if _, ok := tryApplyRefinement(v, (*cty.RefinementBuilder).NotNull); !ok {
+				return cty.UnknownVal(retType), function.NewArgError(0, nullValueError(""))
+			}
+			attrs = make(map[string]cty.Value, len(ty.AttributeTypes()))
+			for name, aty := range ty.AttributeTypes() {
+				attrs[name] = cty.UnknownVal(aty)
+			}
+		}
+		for _, name := range names {
+			attr := attrs[name]
+			if attr.IsKnown() && attr.IsNull() {
+				return cty.UnknownVal(retType), function.NewArgError(0, nullValueError(formatPath(cty.GetAttrPath(name))))
+			}
+			refined, ok := tryApplyRefinement(attr, (*cty.RefinementBuilder).NotNull)
+			if !ok {
+				return cty.UnknownVal(retType), function.NewArgError(0, nullValueError(formatPath(cty.GetAttrPath(name))))
+			}
+			attrs[name] = refined
+		}
+		if len(attrs) == 0 {
+			return cty.EmptyObjectVal, nil
+		}
+		return cty.ObjectVal(attrs), nil
+	},
+}
+
+// refineNotNullDeep recursively refines the given value and all of the
+// values nested inside it as being not null, or returns an error describing
+// the first null value it finds.
+//
+// Unknown objects and tuples are expanded into known values with unknown
+// attributes or elements, because values of those types have a fixed
+// structure decided by their type and so once we know they are not null
+// we can know their structure. Unknown collections are only refined as
+// not null, because we can't know how many elements they will have.
+//
+// The path argument is the path from the top-level value to v, for use in
+// error messages. Its attribute names and keys all come from the value
+// itself, so the error messages must redact them.
+func refineNotNullDeep(v cty.Value, path cty.Path) (cty.Value, error) {
+	if v == cty.DynamicVal {
+		// We can't say anything about a value whose type we don't know.
+		return v, nil
+	}
+	if v.IsKnown() && v.IsNull() {
+		return cty.NilVal, nullValueError(formatActualPath(path))
+	}
+
+	ty := v.Type()
+	if !v.IsKnown() {
+		refined, ok := tryApplyRefinement(v, (*cty.RefinementBuilder).NotNull)
+		if !ok {
+			return cty.NilVal, nullValueError(formatActualPath(path))
+		}
+		switch {
+		case ty.IsObjectType():
+			attrs := make(map[string]cty.Value, len(ty.AttributeTypes()))
+			for name, aty := range ty.AttributeTypes() {
+				attrs[name] = cty.UnknownVal(aty)
+			}
+			if len(attrs) == 0 {
+				return cty.EmptyObjectVal, nil
+			}
+			v = cty.ObjectVal(attrs)
+		case ty.IsTupleType():
+			etys := ty.TupleElementTypes()
+			elems := make([]cty.Value, len(etys))
+			for i, ety := range etys {
+				elems[i] = cty.UnknownVal(ety)
+			}
+			if len(elems) == 0 {
+				return cty.EmptyTupleVal, nil
+			}
+			v = cty.TupleVal(elems)
+		default:
+			return refined, nil
+		}
+	}
+
+	switch {
+	case ty.IsObjectType() || ty.IsMapType():
+		elems := v.AsValueMap()
+		if len(elems) == 0 {
+			return v, nil
+		}
+		keys := make([]string, 0, len(elems))
+		for k := range elems {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			var childPath cty.Path
+			if ty.IsObjectType() {
+				childPath = path.Copy().GetAttr(k)
+			} else {
+				childPath = path.Copy().IndexString(k)
+			}
+			child, err := refineNotNullDeep(elems[k], childPath)
+			if err != nil {
+				return cty.NilVal, err
+			}
+			elems[k] = child
+		}
+		if ty.IsObjectType() {
+			return cty.ObjectVal(elems), nil
+		}
+		return cty.MapVal(elems), nil
+	case ty.IsListType() || ty.IsTupleType() || ty.IsSetType():
+		elems := v.AsValueSlice()
+		if len(elems) == 0 {
+			return v, nil
+		}
+		for i, elem := range elems {
+			childPath := path.Copy().IndexInt(i)
+			if ty.IsSetType() {
+				childPath = path.Copy().Index(elem)
+			}
+			child, err := refineNotNullDeep(elem, childPath)
+			if err != nil {
+				return cty.NilVal, err
+			}
+			elems[i] = child
+		}
+		switch {
+		case ty.IsTupleType():
+			return cty.TupleVal(elems), nil
+		case ty.IsSetType():
+			return cty.SetVal(elems), nil
+		default:
+			return cty.ListVal(elems), nil
+		}
+	default:
+		return v, nil
+	}
+}
+
+// nullValueError returns an error reporting that the value at the given
+// formatted path is null, where an empty path represents the top-level value.
+func nullValueError(path string) error {
+	if path == "" {
+		return fmt.Errorf("value is null")
+	}
+	return fmt.Errorf("%s is null", path)
+}
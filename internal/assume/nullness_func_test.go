@@ -0,0 +1,177 @@
+package assume
+
+import (
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestNullnessFuncs(t *testing.T) {
+	testProviderFuncs(t, funcTests{
+		"notnulldeep": {
+			"dynamicval": {
+				Args: []cty.Value{
+					cty.DynamicVal,
+				},
+				Want: cty.DynamicVal,
+			},
+			"unknown string": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+				},
+				Want: cty.UnknownVal(cty.String).RefineNotNull(),
+			},
+			"unknown object": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.Object(map[string]cty.Type{
+						"id":   cty.String,
+						"tags": cty.Map(cty.String),
+						"any":  cty.DynamicPseudoType,
+						"pair": cty.Tuple([]cty.Type{cty.Number, cty.Bool}),
+					})),
+				},
+				Want: cty.ObjectVal(map[string]cty.Value{
+					"id":   cty.UnknownVal(cty.String).RefineNotNull(),
+					"tags": cty.UnknownVal(cty.Map(cty.String)).RefineNotNull(),
+					"any":  cty.DynamicVal,
+					"pair": cty.TupleVal([]cty.Value{
+						cty.UnknownVal(cty.Number).RefineNotNull(),
+						cty.UnknownVal(cty.Bool).RefineNotNull(),
+					}),
+				}),
+			},
+			"known object with unknown leaves": {
+				Args: []cty.Value{
+					cty.ObjectVal(map[string]cty.Value{
+						"id": cty.StringVal("a"),
+						"subnets": cty.ListVal([]cty.Value{
+							cty.UnknownVal(cty.String),
+						}),
+						"ports": cty.SetVal([]cty.Value{
+							cty.NumberIntVal(80),
+						}),
+						"tags": cty.MapVal(map[string]cty.Value{
+							"Name": cty.UnknownVal(cty.String),
+						}),
+					}),
+				},
+				Want: cty.ObjectVal(map[string]cty.Value{
+					"id": cty.StringVal("a"),
+					"subnets": cty.ListVal([]cty.Value{
+						cty.UnknownVal(cty.String).RefineNotNull(),
+					}),
+					"ports": cty.SetVal([]cty.Value{
+						cty.NumberIntVal(80),
+					}),
+					"tags": cty.MapVal(map[string]cty.Value{
+						"Name": cty.UnknownVal(cty.String).RefineNotNull(),
+					}),
+				}),
+			},
+			"null value": {
+				Args: []cty.Value{
+					cty.NullVal(cty.String),
+				},
+				WantErr: `value is null`,
+			},
+			"nested null value": {
+				Args: []cty.Value{
+					cty.ObjectVal(map[string]cty.Value{
+						"a": cty.StringVal("a"),
+						"network": cty.ObjectVal(map[string]cty.Value{
+							"subnet_ids": cty.ListVal([]cty.Value{
+								cty.StringVal("subnet-1"),
+								cty.NullVal(cty.String),
+							}),
+						}),
+						"z": cty.NullVal(cty.String),
+					}),
+				},
+				WantErr: `.network.subnet_ids[1] is null`,
+			},
+			"null map element": {
+				Args: []cty.Value{
+					cty.MapVal(map[string]cty.Value{
+						"a": cty.StringVal("a"),
+						"b": cty.NullVal(cty.String),
+					}),
+				},
+				WantErr: `["b"] is null`,
+			},
+		},
+		"notnullattrs": {
+			"dynamicval": {
+				Args: []cty.Value{
+					cty.DynamicVal,
+					cty.SetVal([]cty.Value{cty.StringVal("id")}),
+				},
+				Want: cty.DynamicVal,
+			},
+			"unknown object": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.Object(map[string]cty.Type{
+						"id":   cty.String,
+						"arn":  cty.String,
+						"name": cty.String,
+					})),
+					cty.SetVal([]cty.Value{cty.StringVal("id"), cty.StringVal("arn")}),
+				},
+				Want: cty.ObjectVal(map[string]cty.Value{
+					"id":   cty.UnknownVal(cty.String).RefineNotNull(),
+					"arn":  cty.UnknownVal(cty.String).RefineNotNull(),
+					"name": cty.UnknownVal(cty.String),
+				}),
+			},
+			"known object": {
+				Args: []cty.Value{
+					cty.ObjectVal(map[string]cty.Value{
+						"id":   cty.StringVal("a"),
+						"arn":  cty.UnknownVal(cty.String),
+						"name": cty.NullVal(cty.String),
+					}),
+					cty.SetVal([]cty.Value{cty.StringVal("id"), cty.StringVal("arn")}),
+				},
+				Want: cty.ObjectVal(map[string]cty.Value{
+					"id":   cty.StringVal("a"),
+					"arn":  cty.UnknownVal(cty.String).RefineNotNull(),
+					"name": cty.NullVal(cty.String),
+				}),
+			},
+			"null attribute": {
+				Args: []cty.Value{
+					cty.ObjectVal(map[string]cty.Value{
+						"id":  cty.StringVal("a"),
+						"arn": cty.NullVal(cty.String),
+					}),
+					cty.SetVal([]cty.Value{cty.StringVal("id"), cty.StringVal("arn")}),
+				},
+				WantErr: `.arn is null`,
+			},
+			"null object": {
+				Args: []cty.Value{
+					cty.NullVal(cty.Object(map[string]cty.Type{
+						"id": cty.String,
+					})),
+					cty.SetVal([]cty.Value{cty.StringVal("id")}),
+				},
+				WantErr: `value is null`,
+			},
+			"nonexistent attribute": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.Object(map[string]cty.Type{
+						"id": cty.String,
+					})),
+					cty.SetVal([]cty.Value{cty.StringVal("arn")}),
+				},
+				WantErr: `object has no attribute "arn"`,
+			},
+			"not an object": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.Map(cty.String)),
+					cty.SetVal([]cty.Value{cty.StringVal("arn")}),
+				},
+				WantErr: `must be an object`,
+			},
+		},
+	})
+}
@@ -0,0 +1,159 @@
+package assume
+
+import (
+	"fmt"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+var integerFunc = makeNumberDomainFunc(
+	"Assume that the given number will be a whole number.",
+	nil,
+	wholeNumberRule,
+)
+
+var nonnegativeFunc = makeNumberDomainFunc(
+	"Assume that the given number will be greater than or equal to zero.",
+	func(b *cty.RefinementBuilder) *cty.RefinementBuilder {
+		return b.NumberRangeLowerBound(cty.Zero, true)
+	},
+	numberLowerBoundRule(cty.Zero, true),
+)
+
+var positiveFunc = makeNumberDomainFunc(
+	"Assume that the given number will be greater than zero.",
+	func(b *cty.RefinementBuilder) *cty.RefinementBuilder {
+		return b.NumberRangeLowerBound(cty.Zero, false)
+	},
+	numberLowerBoundRule(cty.Zero, false),
+)
+
+var portFunc = makeNumberDomainFunc(
+	"Assume that the given number will be a valid TCP or UDP port number.",
+	func(b *cty.RefinementBuilder) *cty.RefinementBuilder {
+		return b.NumberRangeInclusive(cty.NumberIntVal(1), cty.NumberIntVal(65535))
+	},
+	wholeNumberRule,
+	numberLowerBoundRule(cty.NumberIntVal(1), true),
+	numberUpperBoundRule(cty.NumberIntVal(65535), true),
+)
+
+var percentageFunc = makeNumberDomainFunc(
+	"Assume that the given number will be a percentage between 0 and 100.",
+	func(b *cty.RefinementBuilder) *cty.RefinementBuilder {
+		return b.NumberRangeInclusive(cty.Zero, cty.NumberIntVal(100))
+	},
+	numberLowerBoundRule(cty.Zero, true),
+	numberUpperBoundRule(cty.NumberIntVal(100), true),
+)
+
+// numberRule is a rule that a known number must conform to. It returns a
+// description of the problem if the given number does not conform, or an
+// empty string if it does.
+type numberRule func(v cty.Value) string
+
+func wholeNumberRule(v cty.Value) string {
+	if !v.AsBigFloat().IsInt() {
+		return "is not a whole number"
+	}
+	return ""
+}
+
+func numberLowerBoundRule(min cty.Value, inclusive bool) numberRule {
+	if inclusive {
+		return func(v cty.Value) string {
+			if v.LessThan(min).True() {
+				return "is less than " + simpleDisplayValue(min)
+			}
+			return ""
+		}
+	}
+	return func(v cty.Value) string {
+		if v.LessThanOrEqualTo(min).True() {
+			return "is not greater than " + simpleDisplayValue(min)
+		}
+		return ""
+	}
+}
+
+func numberUpperBoundRule(max cty.Value, inclusive bool) numberRule {
+	if inclusive {
+		return func(v cty.Value) string {
+			if v.GreaterThan(max).True() {
+				return "is greater than " + simpleDisplayValue(max)
+			}
+			return ""
+		}
+	}
+	return func(v cty.Value) string {
+		if v.GreaterThanOrEqualTo(max).True() {
+			return "is not less than " + simpleDisplayValue(max)
+		}
+		return ""
+	}
+}
+
+// makeNumberDomainFunc builds a function that makes an assumption about
+// a number that might be more specific than cty's refinements can represent.
+//
+// While the given value is unknown the function applies the refinements
+// from the given refine callback, which may be nil if there are no
+// applicable refinements. Once the value is known the function instead checks
+// each of the given rules in turn and reports the first one that fails.
+func makeNumberDomainFunc(desc string, refine func(b *cty.RefinementBuilder) *cty.RefinementBuilder, rules ...numberRule) *function.Spec {
+	return &function.Spec{
+		Description: desc,
+		Params: []function.Parameter{
+			{
+				Name:         "value",
+				Type:         cty.Number,
+				Description:  "The value to make the assumption about.",
+				AllowNull:    true,
+				AllowUnknown: true,
+			},
+		},
+		Type: function.StaticReturnType(cty.Number),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			v := args[0]
+			if v.IsKnown() {
+				if v.IsNull() {
+					return cty.UnknownVal(cty.Number), function.NewArgErrorf(0, "value must not be null")
+				}
+				for _, rule := range rules {
+					if problem := rule(v); problem != "" {
+						return cty.UnknownVal(cty.Number), function.NewArgError(0, fmt.Errorf("%s %s", displayActualValue(v), problem))
+					}
+				}
+				return v, nil
+			}
+			if refine == nil {
+				return v, nil
+			}
+			ret, ok := tryApplyRefinement(v, refine)
+			if !ok {
+				return cty.UnknownVal(cty.Number), function.NewArgError(0, explainNumberDomain(v, refine))
+			}
+			return ret, nil
+		},
+	}
+}
+
+// explainNumberDomain returns an error describing why the given unknown
+// number cannot be refined with the given refinements.
+func explainNumberDomain(v cty.Value, refine func(b *cty.RefinementBuilder) *cty.RefinementBuilder) error {
+	// The refinements don't directly tell us which bounds they apply, so
+	// we'll apply them to an unconstrained unknown number to find out.
+	assumed := cty.UnknownVal(cty.Number).RefineWith(refine).Range()
+	if min, inclusive := assumed.NumberLowerBound(); min.IsKnown() && min != cty.NegativeInfinity {
+		if err := explainNumberRange(v, min, cty.NilVal, inclusive); err != nil {
+			return err
+		}
+	}
+	if max, inclusive := assumed.NumberUpperBound(); max.IsKnown() && max != cty.PositiveInfinity {
+		if err := explainNumberRange(v, cty.NilVal, max, inclusive); err != nil {
+			return err
+		}
+	}
+	return errAssumptionNotUpheld
+}
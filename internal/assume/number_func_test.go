@@ -0,0 +1,146 @@
+package assume
+
+import (
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestNumberDomainFuncs(t *testing.T) {
+	testProviderFuncs(t, funcTests{
+		"integer": {
+			"unknown number": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.Number),
+				},
+				Want: cty.UnknownVal(cty.Number),
+			},
+			"whole number": {
+				Args: []cty.Value{
+					cty.NumberIntVal(-3),
+				},
+				Want: cty.NumberIntVal(-3),
+			},
+			"fractional number": {
+				Args: []cty.Value{
+					cty.NumberFloatVal(3.5),
+				},
+				WantErr: `3.5 is not a whole number`,
+			},
+			"null number": {
+				Args: []cty.Value{
+					cty.NullVal(cty.Number),
+				},
+				WantErr: `value must not be null`,
+			},
+		},
+		"nonnegative": {
+			"unknown number": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.Number),
+				},
+				Want: cty.UnknownVal(cty.Number).Refine().
+					NumberRangeLowerBound(cty.Zero, true).
+					NewValue(),
+			},
+			"zero": {
+				Args: []cty.Value{
+					cty.Zero,
+				},
+				Want: cty.Zero,
+			},
+			"negative number": {
+				Args: []cty.Value{
+					cty.NumberFloatVal(-0.5),
+				},
+				WantErr: `-0.5 is less than 0`,
+			},
+			"unknown number with conflicting range": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.Number).Refine().
+						NumberRangeUpperBound(cty.NumberIntVal(-1), true).
+						NewValue(),
+				},
+				WantErr: "value already known to be at most -1 which conflicts with assumed minimum 0",
+			},
+		},
+		"positive": {
+			"unknown number": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.Number),
+				},
+				Want: cty.UnknownVal(cty.Number).Refine().
+					NumberRangeLowerBound(cty.Zero, false).
+					NewValue(),
+			},
+			"positive number": {
+				Args: []cty.Value{
+					cty.NumberFloatVal(0.1),
+				},
+				Want: cty.NumberFloatVal(0.1),
+			},
+			"zero": {
+				Args: []cty.Value{
+					cty.Zero,
+				},
+				WantErr: `0 is not greater than 0`,
+			},
+		},
+		"port": {
+			"unknown number": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.Number),
+				},
+				Want: cty.UnknownVal(cty.Number).Refine().
+					NumberRangeInclusive(cty.NumberIntVal(1), cty.NumberIntVal(65535)).
+					NewValue(),
+			},
+			"valid port": {
+				Args: []cty.Value{
+					cty.NumberIntVal(443),
+				},
+				Want: cty.NumberIntVal(443),
+			},
+			"fractional number": {
+				Args: []cty.Value{
+					cty.NumberFloatVal(80.5),
+				},
+				WantErr: `80.5 is not a whole number`,
+			},
+			"zero": {
+				Args: []cty.Value{
+					cty.Zero,
+				},
+				WantErr: `0 is less than 1`,
+			},
+			"too large": {
+				Args: []cty.Value{
+					cty.NumberIntVal(65536),
+				},
+				WantErr: `65536 is greater than 65535`,
+			},
+		},
+		"percentage": {
+			"unknown number": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.Number),
+				},
+				Want: cty.UnknownVal(cty.Number).Refine().
+					NumberRangeInclusive(cty.Zero, cty.NumberIntVal(100)).
+					NewValue(),
+			},
+			"fractional percentage": {
+				Args: []cty.Value{
+					cty.NumberFloatVal(99.5),
+				},
+				Want: cty.NumberFloatVal(99.5),
+			},
+			"too large": {
+				Args: []cty.Value{
+					cty.NumberIntVal(150),
+				},
+				WantErr: `150 is greater than 100`,
+			},
+		},
+	})
+}
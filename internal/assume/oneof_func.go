@@ -0,0 +1,190 @@
+package assume
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+var oneofFunc = &function.Spec{
+	Description: "Assume that the given value will be equal to one of the given candidate values.",
+	Params: []function.Parameter{
+		{
+			Name:             "value",
+			Type:             cty.DynamicPseudoType,
+			Description:      "The value to make the assumption about.",
+			AllowNull:        true,
+			AllowUnknown:     true,
+			AllowDynamicType: true,
+		},
+		{
+			Name:             "candidates",
+			Type:             cty.DynamicPseudoType,
+			Description:      "A collection of the values that the first argument might be equal to.",
+			AllowDynamicType: true,
+		},
+	},
+	Type: func(args []cty.Value) (cty.Type, error) {
+		return cty.DynamicPseudoType, nil
+	},
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		v := args[0]
+		candidates, err := oneofCandidates(args[1], v.Type())
+		if err != nil {
+			return cty.DynamicVal, function.NewArgError(1, err)
+		}
+		if candidates == nil {
+			// The candidates are not yet known, so we can't make any
+			// assumptions yet.
+			return v, nil
+		}
+
+		if v.IsKnown() {
+			// If the value is only partially known then we can only check
+			// whether it definitely doesn't match any of the candidates.
+			for _, candidate := range candidates {
+				if eq := v.Equals(candidate); !eq.IsKnown() || eq.True() {
+					return v, nil
+				}
+			}
+			return cty.DynamicVal, function.NewArgError(0, oneofMismatchError(v, candidates))
+		}
+
+		if len(candidates) == 1 {
+			// If there's only one candidate then the value can only be
+			// that candidate, but we must still check that the candidate
+			// is consistent with any existing refinements.
+			if inc := v.Range().Includes(candidates[0]); inc.IsKnown() && inc.False() {
+				return cty.DynamicVal, function.NewArgError(0, oneofMismatchError(v, candidates))
+			}
+			return candidates[0], nil
+		}
+		ret, ok := tryApplyRefinement(v, oneofRefinements(candidates))
+		if !ok {
+			return cty.DynamicVal, function.NewArgError(0, oneofMismatchError(v, candidates))
+		}
+		return ret, nil
+	},
+}
+
+// oneofCandidates decodes the candidates argument of oneof, converting each
+// of the candidates to the type of the value they will be compared with.
+//
+// If the candidates are not yet wholly known then the result is nil with
+// no error.
+func oneofCandidates(raw cty.Value, ty cty.Type) ([]cty.Value, error) {
+	rawTy := raw.Type()
+	if !(rawTy.IsListType() || rawTy.IsSetType() || rawTy.IsTupleType()) {
+		return nil, fmt.Errorf("must be a list of candidate values")
+	}
+	if !raw.IsWhollyKnown() {
+		return nil, nil
+	}
+	if raw.LengthInt() == 0 {
+		return nil, fmt.Errorf("must have at least one candidate value")
+	}
+	ret := make([]cty.Value, 0, raw.LengthInt())
+	for it := raw.ElementIterator(); it.Next(); {
+		_, candidate := it.Element()
+		candidate, err := convert.Convert(candidate, ty)
+		if err != nil {
+			return nil, fmt.Errorf("all candidates must be of the same type as the value, %s", ty.FriendlyName())
+		}
+		ret = append(ret, candidate)
+	}
+	return ret, nil
+}
+
+// oneofRefinements returns a refinement function that applies all of the
+// refinements that are shared by all of the given candidate values.
+func oneofRefinements(candidates []cty.Value) func(*cty.RefinementBuilder) *cty.RefinementBuilder {
+	return func(b *cty.RefinementBuilder) *cty.RefinementBuilder {
+		var nonNull []cty.Value
+		for _, candidate := range candidates {
+			if !candidate.IsNull() {
+				nonNull = append(nonNull, candidate)
+			}
+		}
+		if len(nonNull) == 0 {
+			// Only null candidates, which is strange but valid.
+			return b.Null()
+		}
+		if len(nonNull) == len(candidates) {
+			b = b.NotNull()
+		}
+
+		ty := nonNull[0].Type()
+		switch {
+		case ty == cty.String:
+			prefix := nonNull[0].AsString()
+			for _, candidate := range nonNull[1:] {
+				prefix = commonPrefix(prefix, candidate.AsString())
+			}
+			if prefix != "" {
+				b = b.StringPrefix(prefix)
+			}
+		case ty == cty.Number:
+			min, max := nonNull[0], nonNull[0]
+			for _, candidate := range nonNull[1:] {
+				if candidate.LessThan(min).True() {
+					min = candidate
+				}
+				if candidate.GreaterThan(max).True() {
+					max = candidate
+				}
+			}
+			b = b.NumberRangeInclusive(min, max)
+		case ty.IsCollectionType():
+			min, max := nonNull[0].LengthInt(), nonNull[0].LengthInt()
+			for _, candidate := range nonNull[1:] {
+				l := candidate.LengthInt()
+				if l < min {
+					min = l
+				}
+				if l > max {
+					max = l
+				}
+			}
+			b = b.CollectionLengthLowerBound(min).CollectionLengthUpperBound(max)
+		}
+		return b
+	}
+}
+
+// commonPrefix returns the longest prefix shared by both of the given
+// strings, without splitting any multi-byte characters.
+func commonPrefix(a, b string) string {
+	for i, r := range a {
+		// The previous characters all matched, so i is also a valid offset
+		// into b.
+		if !strings.HasPrefix(b[i:], string(r)) {
+			return a[:i]
+		}
+	}
+	return a
+}
+
+func oneofMismatchError(v cty.Value, candidates []cty.Value) error {
+	var allowed []string
+	for _, candidate := range candidates {
+		s := displayActualValue(candidate)
+		if s == "" {
+			allowed = nil
+			break
+		}
+		allowed = append(allowed, s)
+	}
+	var subject string
+	if vStr := displayActualValue(v); vStr != "" {
+		subject = "the value " + vStr
+	} else {
+		subject = "the value"
+	}
+	if allowed == nil {
+		return fmt.Errorf("%s is not one of the %d allowed values", subject, len(candidates))
+	}
+	return fmt.Errorf("%s is not one of the allowed values: %s", subject, strings.Join(allowed, ", "))
+}
@@ -0,0 +1,150 @@
+package assume
+
+import (
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestOneofFunc(t *testing.T) {
+	testProviderFuncs(t, funcTests{
+		"oneof": {
+			"dynamicval": {
+				Args: []cty.Value{
+					cty.DynamicVal,
+					cty.TupleVal([]cty.Value{cty.StringVal("us-east-1"), cty.StringVal("us-west-2")}),
+				},
+				Want: cty.DynamicVal,
+			},
+			"dynamicval with one candidate": {
+				Args: []cty.Value{
+					cty.DynamicVal,
+					cty.TupleVal([]cty.Value{cty.StringVal("us-east-1")}),
+				},
+				Want: cty.StringVal("us-east-1"),
+			},
+			"unknown string": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.TupleVal([]cty.Value{cty.StringVal("us-east-1"), cty.StringVal("us-west-2")}),
+				},
+				Want: cty.UnknownVal(cty.String).Refine().
+					NotNull().
+					StringPrefixFull("us-").
+					NewValue(),
+			},
+			"unknown string with null candidate": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.TupleVal([]cty.Value{cty.StringVal("a-b"), cty.NullVal(cty.String), cty.StringVal("a-c")}),
+				},
+				Want: cty.UnknownVal(cty.String).Refine().
+					StringPrefixFull("a-").
+					NewValue(),
+			},
+			"unknown string with multi-byte characters": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.TupleVal([]cty.Value{cty.StringVal("a-é-"), cty.StringVal("a-è-")}),
+				},
+				Want: cty.UnknownVal(cty.String).Refine().
+					NotNull().
+					StringPrefixFull("a-").
+					NewValue(),
+			},
+			"unknown number": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.Number),
+					cty.TupleVal([]cty.Value{cty.NumberIntVal(5), cty.NumberIntVal(1), cty.NumberIntVal(3)}),
+				},
+				Want: cty.UnknownVal(cty.Number).Refine().
+					NotNull().
+					NumberRangeInclusive(cty.NumberIntVal(1), cty.NumberIntVal(5)).
+					NewValue(),
+			},
+			"unknown list": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.List(cty.String)),
+					cty.TupleVal([]cty.Value{
+						cty.TupleVal([]cty.Value{cty.StringVal("a")}),
+						cty.TupleVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b")}),
+					}),
+				},
+				Want: cty.UnknownVal(cty.List(cty.String)).Refine().
+					NotNull().
+					CollectionLengthLowerBound(1).
+					CollectionLengthUpperBound(2).
+					NewValue(),
+			},
+			"unknown value with one candidate": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.ListVal([]cty.Value{cty.StringVal("us-east-1")}),
+				},
+				Want: cty.StringVal("us-east-1"),
+			},
+			"unknown value with one conflicting candidate": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String).Refine().StringPrefix("eu-").NewValue(),
+					cty.ListVal([]cty.Value{cty.StringVal("us-east-1")}),
+				},
+				WantErr: "the value (a string starting with \"eu-\") is not one of the allowed values: \"us-east-1\"",
+			},
+			"unknown value with conflicting candidates": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String).Refine().StringPrefix("eu-").NewValue(),
+					cty.ListVal([]cty.Value{cty.StringVal("us-east-1"), cty.StringVal("us-west-2")}),
+				},
+				WantErr: "the value (a string starting with \"eu-\") is not one of the allowed values: \"us-east-1\", \"us-west-2\"",
+			},
+			"known value in candidates": {
+				Args: []cty.Value{
+					cty.StringVal("us-west-2"),
+					cty.SetVal([]cty.Value{cty.StringVal("us-east-1"), cty.StringVal("us-west-2")}),
+				},
+				Want: cty.StringVal("us-west-2"),
+			},
+			"known value not in candidates": {
+				Args: []cty.Value{
+					cty.StringVal("eu-west-1"),
+					cty.TupleVal([]cty.Value{cty.StringVal("us-east-1"), cty.StringVal("us-west-2")}),
+				},
+				WantErr: `the value "eu-west-1" is not one of the allowed values: "us-east-1", "us-west-2"`,
+			},
+			"known complex value not in candidates": {
+				Args: []cty.Value{
+					cty.ListVal([]cty.Value{cty.StringVal("c")}),
+					cty.TupleVal([]cty.Value{
+						cty.TupleVal([]cty.Value{cty.StringVal("a")}),
+						cty.TupleVal([]cty.Value{cty.StringVal("b")}),
+					}),
+				},
+				WantErr: `the value is not one of the 2 allowed values`,
+			},
+			"partially-known value that might match": {
+				Args: []cty.Value{
+					cty.ListVal([]cty.Value{cty.UnknownVal(cty.String)}),
+					cty.TupleVal([]cty.Value{
+						cty.TupleVal([]cty.Value{cty.StringVal("a")}),
+						cty.TupleVal([]cty.Value{cty.StringVal("b"), cty.StringVal("c")}),
+					}),
+				},
+				Want: cty.ListVal([]cty.Value{cty.UnknownVal(cty.String)}),
+			},
+			"no candidates": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.EmptyTupleVal,
+				},
+				WantErr: `must have at least one candidate value`,
+			},
+			"candidate of wrong type": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.Number),
+					cty.TupleVal([]cty.Value{cty.StringVal("a")}),
+				},
+				WantErr: `all candidates must be of the same type as the value, number`,
+			},
+		},
+	})
+}
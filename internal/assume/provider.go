@@ -1,22 +1,76 @@
 package assume
 
 import (
+	"os"
+
 	"github.com/apparentlymart/go-tf-func-provider/tffunc"
+	"github.com/zclconf/go-cty/cty/function"
 )
 
 func NewProvider() *tffunc.Provider {
 	p := tffunc.NewProvider()
-	p.AddFunction("notnull", notnullFunc)
-	p.AddFunction("equal", equalFunc)
-	p.AddFunction("stringprefix", stringprefixFunc)
-	p.AddFunction("listlength", listlengthFunc)
-	p.AddFunction("listlengthmin", listlengthminFunc)
-	p.AddFunction("listlengthmax", listlengthmaxFunc)
-	p.AddFunction("setlength", setlengthFunc)
-	p.AddFunction("setlengthmin", setlengthminFunc)
-	p.AddFunction("setlengthmax", setlengthmaxFunc)
-	p.AddFunction("maplength", maplengthFunc)
-	p.AddFunction("maplengthmin", maplengthminFunc)
-	p.AddFunction("maplengthmax", maplengthmaxFunc)
+	// This changes the mode for every provider in the process; see the
+	// documentation of redaction for why that's okay.
+	redaction = parseRedactionMode(os.Getenv(redactionEnvVar))
+
+	// Every function accepts an optional final argument explaining why the
+	// assumption should hold, so we add that to each function here.
+	add := func(name string, spec *function.Spec) {
+		p.AddFunction(name, withReason(spec))
+	}
+
+	add("notnull", notnullFunc)
+	add("equal", equalFunc)
+	add("jsonequal", jsonequalFunc)
+	add("equalfold", equalfoldFunc)
+	add("unorderedequal", unorderedequalFunc)
+	add("equalwithin", equalwithinFunc)
+	add("stringprefix", stringprefixFunc)
+	add("stringmatch", stringmatchFunc)
+	add("stringtemplate", stringtemplateFunc)
+	add("awsarn", awsarnFunc)
+	add("azureresourceid", azureresourceidFunc)
+	add("gcpselflink", gcpselflinkFunc)
+	add("urlassume", urlassumeFunc)
+	add("ipincidr", ipincidrFunc)
+	add("cidrwithin", cidrwithinFunc)
+	add("hostname", hostnameFunc)
+	add("dnslabel", dnslabelFunc)
+	add("k8sname", k8snameFunc)
+	add("uuid", uuidFunc)
+	add("lowerhex", lowerhexFunc)
+	add("upperhex", upperhexFunc)
+	add("base64", base64Func)
+	add("base64url", base64urlFunc)
+	add("stringlength", stringlengthFunc)
+	add("semver", semverFunc)
+	add("listlength", listlengthFunc)
+	add("listlengthmin", listlengthminFunc)
+	add("listlengthmax", listlengthmaxFunc)
+	add("setlength", setlengthFunc)
+	add("setlengthmin", setlengthminFunc)
+	add("setlengthmax", setlengthmaxFunc)
+	add("maplength", maplengthFunc)
+	add("maplengthmin", maplengthminFunc)
+	add("maplengthmax", maplengthmaxFunc)
+	add("numberrange", numberrangeFunc)
+	add("numbermin", numberminFunc)
+	add("numbermax", numbermaxFunc)
+	add("integer", integerFunc)
+	add("nonnegative", nonnegativeFunc)
+	add("positive", positiveFunc)
+	add("port", portFunc)
+	add("percentage", percentageFunc)
+	add("mapkeys", mapkeysFunc)
+	add("listof", listofFunc)
+	add("tupleof", tupleofFunc)
+	add("typed", typedFunc)
+	add("jsonstring", jsonstringFunc)
+	add("jsondecoded", jsondecodedFunc)
+	add("all", allFunc)
+	add("at", atFunc)
+	add("notnulldeep", notnulldeepFunc)
+	add("notnullattrs", notnullattrsFunc)
+	add("oneof", oneofFunc)
 	return p
 }
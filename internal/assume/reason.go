@@ -0,0 +1,118 @@
+package assume
+
+import (
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// withReason returns a copy of the given function that also accepts an
+// optional trailing string argument describing why the author believes the
+// assumption to be true. If the function then fails because the assumption
+// was not upheld, the given reason is included in the error message.
+//
+// If the given function already has a variadic parameter then the reason
+// can follow any variadic arguments. The arguments are then told apart by
+// their types alone: a final argument of type string is always the reason,
+// and any other arguments must convert to the type of the given function's
+// own variadic parameter, which must therefore not itself be a string.
+func withReason(spec *function.Spec) *function.Spec {
+	ret := *spec // shallow copy
+	innerVar := spec.VarParam
+	if innerVar == nil {
+		ret.VarParam = &function.Parameter{
+			Name:         "reason",
+			Type:         cty.String,
+			Description:  "An optional explanation of why the assumption should hold, to include in the error message if it doesn't.",
+			AllowNull:    true,
+			AllowUnknown: true,
+		}
+	} else {
+		// The variadic parameter must accept both the wrapped function's
+		// own variadic arguments and the reason, so we check its type
+		// constraint ourselves in splitReason below.
+		ret.VarParam = &function.Parameter{
+			Name:             innerVar.Name,
+			Type:             cty.DynamicPseudoType,
+			Description:      innerVar.Description + " An explanation of why the assumption should hold may also be given as a final string argument, to include in the error message if it doesn't.",
+			AllowNull:        true,
+			AllowUnknown:     true,
+			AllowDynamicType: true,
+		}
+	}
+
+	// splitReason separates the reason argument, if any, from the arguments
+	// intended for the wrapped function. An unknown reason is treated as if
+	// there were no reason, since it can't be included in error messages.
+	//
+	// The final result is true if any of the wrapped function's variadic
+	// arguments are unknown but its variadic parameter doesn't allow that,
+	// in which case the wrapped function must not be called.
+	splitReason := func(args []cty.Value) ([]cty.Value, string, bool, error) {
+		fixed := len(spec.Params)
+		var reason string
+		if len(args) > fixed {
+			last := args[len(args)-1]
+			if last.Type() == cty.String {
+				if last.IsKnown() && !last.IsNull() {
+					reason = last.AsString()
+				}
+				args = args[:len(args)-1]
+			}
+		}
+		if len(args) == fixed {
+			return args, reason, false, nil
+		}
+		if innerVar == nil {
+			// Only the reason can follow the fixed arguments, and so
+			// cty has already checked that it's a string.
+			return nil, "", false, function.NewArgErrorf(fixed+1, "too many arguments; only one reason is allowed")
+		}
+		// The remaining arguments belong to the wrapped function's own
+		// variadic parameter, so we must enforce its type constraint.
+		args = append([]cty.Value(nil), args...)
+		unknown := false
+		for i := fixed; i < len(args); i++ {
+			v, err := convert.Convert(args[i], innerVar.Type)
+			if err != nil {
+				return nil, "", false, function.NewArgError(i, err)
+			}
+			if v.IsNull() && !innerVar.AllowNull {
+				return nil, "", false, function.NewArgErrorf(i, "argument must not be null")
+			}
+			if !v.IsKnown() && !innerVar.AllowUnknown {
+				unknown = true
+			}
+			args[i] = v
+		}
+		return args, reason, unknown, nil
+	}
+
+	ret.Type = func(args []cty.Value) (cty.Type, error) {
+		args, _, _, err := splitReason(args)
+		if err != nil {
+			return cty.DynamicPseudoType, err
+		}
+		return spec.Type(args)
+	}
+	ret.Impl = func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		args, reason, unknown, err := splitReason(args)
+		if err != nil {
+			return cty.UnknownVal(retType), err
+		}
+		if unknown {
+			return cty.UnknownVal(retType), nil
+		}
+		result, err := spec.Impl(args, retType)
+		if err != nil && reason != "" {
+			// Only errors about the first argument describe an assumption
+			// that wasn't upheld. Errors about other arguments describe
+			// problems with how the function was called.
+			if argErr, ok := err.(function.ArgError); ok && argErr.Index == 0 {
+				err = function.NewArgErrorf(0, "%s\nReason for assumption: %s", argErr.Error(), reason)
+			}
+		}
+		return result, err
+	}
+	return &ret
+}
@@ -0,0 +1,177 @@
+package assume
+
+import (
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestReasonArgument(t *testing.T) {
+	testProviderFuncs(t, funcTests{
+		"notnull": {
+			"unknown value with reason": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.StringVal("the API always returns a VPC id once created"),
+				},
+				Want: cty.UnknownVal(cty.String).RefineNotNull(),
+			},
+			"known value with unknown reason": {
+				Args: []cty.Value{
+					cty.StringVal("a"),
+					cty.UnknownVal(cty.String),
+				},
+				Want: cty.StringVal("a"),
+			},
+			"null value with unknown reason": {
+				Args: []cty.Value{
+					cty.NullVal(cty.String),
+					cty.UnknownVal(cty.String),
+				},
+				WantErr: "value is null but was assumed to never be null",
+			},
+			"null value with reason": {
+				Args: []cty.Value{
+					cty.NullVal(cty.String),
+					cty.StringVal("the API always returns a VPC id once created"),
+				},
+				WantErr: "value is null but was assumed to never be null\nReason for assumption: the API always returns a VPC id once created",
+			},
+			"null value with null reason": {
+				Args: []cty.Value{
+					cty.NullVal(cty.String),
+					cty.NullVal(cty.String),
+				},
+				WantErr: "value is null but was assumed to never be null",
+			},
+			"too many reasons": {
+				Args: []cty.Value{
+					cty.NullVal(cty.String),
+					cty.StringVal("a"),
+					cty.StringVal("b"),
+				},
+				WantErr: "too many arguments; only one reason is allowed",
+			},
+		},
+		"equal": {
+			"incorrect with reason": {
+				Args: []cty.Value{
+					cty.StringVal("hello"),
+					cty.StringVal("hi"),
+					cty.StringVal("everyone says hi"),
+				},
+				WantErr: "the actual value \"hello\" does not match the assumed value\nReason for assumption: everyone says hi",
+			},
+		},
+		"listlengthmax": {
+			"too long with reason": {
+				Args: []cty.Value{
+					cty.ListVal([]cty.Value{cty.True, cty.False}),
+					cty.NumberIntVal(1),
+					cty.StringVal("there is only one"),
+				},
+				WantErr: "list has 2 elements but was assumed to have at most 1\nReason for assumption: there is only one",
+			},
+			"invalid bound with reason": {
+				Args: []cty.Value{
+					cty.ListVal([]cty.Value{cty.True, cty.False}),
+					cty.NumberFloatVal(1.5),
+					cty.StringVal("there is only one"),
+				},
+				// The reason is only relevant to failed assumptions, not
+				// to problems with the other arguments.
+				WantErr: "must be a whole number between 0 and 9223372036854775807",
+			},
+		},
+		"numbermin": {
+			"out of range with reason": {
+				Args: []cty.Value{
+					cty.NumberIntVal(0),
+					cty.NumberIntVal(1),
+					cty.StringVal("counts start at one"),
+				},
+				WantErr: "0 is less than assumed minimum 1\nReason for assumption: counts start at one",
+			},
+			"out of range with inclusive flag and reason": {
+				Args: []cty.Value{
+					cty.NumberIntVal(1),
+					cty.NumberIntVal(1),
+					cty.False,
+					cty.StringVal("counts start after one"),
+				},
+				WantErr: "1 is not greater than assumed exclusive minimum 1\nReason for assumption: counts start after one",
+			},
+			"unknown with inclusive flag and reason": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.Number),
+					cty.NumberIntVal(1),
+					cty.False,
+					cty.StringVal("counts start after one"),
+				},
+				Want: cty.UnknownVal(cty.Number).Refine().
+					NumberRangeLowerBound(cty.NumberIntVal(1), false).
+					NewValue(),
+			},
+			"unknown inclusive flag": {
+				Args: []cty.Value{
+					cty.NumberIntVal(1),
+					cty.NumberIntVal(1),
+					cty.UnknownVal(cty.Bool),
+				},
+				Want: cty.UnknownVal(cty.Number),
+			},
+			"invalid inclusive flag": {
+				Args: []cty.Value{
+					cty.NumberIntVal(1),
+					cty.NumberIntVal(1),
+					cty.EmptyObjectVal,
+				},
+				WantErr: "bool required",
+			},
+		},
+		"numberrange": {
+			// A final string argument is always the reason, even if it
+			// could convert to the inclusive flag.
+			"string that could be an inclusive flag": {
+				Args: []cty.Value{
+					cty.NumberIntVal(11),
+					cty.NumberIntVal(1),
+					cty.NumberIntVal(10),
+					cty.StringVal("true"),
+				},
+				WantErr: "11 is greater than assumed maximum 10\nReason for assumption: true",
+			},
+			"inclusive flag and reason": {
+				Args: []cty.Value{
+					cty.NumberIntVal(5),
+					cty.NumberIntVal(1),
+					cty.NumberIntVal(3),
+					cty.True,
+					cty.StringVal("there are only three"),
+				},
+				WantErr: "5 is greater than assumed maximum 3\nReason for assumption: there are only three",
+			},
+		},
+		"uuid": {
+			"string that could be a version": {
+				Args: []cty.Value{
+					cty.StringVal("0190b4a2-6c1e-7d3a-9f4b-2a6c8e0d1f3b"),
+					cty.StringVal("4"),
+				},
+				Want: cty.StringVal("0190b4a2-6c1e-7d3a-9f4b-2a6c8e0d1f3b"),
+			},
+		},
+		"all": {
+			"failed assumption with reason": {
+				Args: []cty.Value{
+					cty.StringVal("foo"),
+					cty.ObjectVal(map[string]cty.Value{
+						"prefix": cty.StringVal("arn:"),
+					}),
+					cty.StringVal("it's an ARN"),
+				},
+				WantErr: "the \"prefix\" assumption was not upheld: value \"foo\" does not start with assumed prefix \"arn:\"\nReason for assumption: it's an ARN",
+			},
+		},
+	})
+}
@@ -0,0 +1,148 @@
+package assume
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+// redactionEnvVar is the name of the environment variable that NewProvider
+// consults to decide the redaction mode.
+const redactionEnvVar = "TF_ASSUME_REDACT"
+
+// redactionMode represents a policy for how error messages may describe
+// the values that an assumption was made about.
+//
+// Provider functions receive values without their sensitivity marks, so we
+// can't rely on Terraform to redact sensitive values in our error messages.
+// Instead, the user can choose a redaction mode that applies to all values.
+type redactionMode int
+
+const (
+	// redactNone means that values are shown in full.
+	redactNone redactionMode = iota
+
+	// redactFingerprint means that values are replaced by the SHA-256
+	// hash of their string representation.
+	redactFingerprint
+
+	// redactSummary means that values are replaced by a description of
+	// their type and, for strings, their length.
+	redactSummary
+
+	// redactAll means that values are not described at all.
+	redactAll
+)
+
+// redaction is the redaction mode used by all error messages, decided by
+// NewProvider based on the environment variable named in redactionEnvVar.
+//
+// The function specs are shared package-level values, so there's nowhere
+// else to keep this. That means that all providers in the same process
+// share the mode of whichever was created most recently, and so tests that
+// change the mode must not run in parallel with any other tests. That's fine
+// for the real plugin, which creates only one provider per process.
+var redaction = redactNone
+
+// parseRedactionMode returns the redaction mode described by the given
+// string, as would be given in the environment variable named in
+// redactionEnvVar.
+//
+// Unrecognized modes are treated as redactAll, so that a typo cannot cause
+// values to be shown when the user intended them to be hidden.
+func parseRedactionMode(s string) redactionMode {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "none":
+		return redactNone
+	case "fingerprint":
+		return redactFingerprint
+	case "summary":
+		return redactSummary
+	default:
+		return redactAll
+	}
+}
+
+// displayActualValue is like simpleDisplayValue, but respects the current
+// redaction mode.
+//
+// Use this for any value that might have been derived from sensitive data,
+// including any part of an unknown value that is already known. Values
+// that are written directly in the function call, like the bounds of a
+// number range, can use simpleDisplayValue directly.
+func displayActualValue(v cty.Value) string {
+	if redaction == redactNone || v.IsNull() || !v.Type().IsPrimitiveType() {
+		return simpleDisplayValue(v)
+	}
+	if !v.IsKnown() {
+		if v.Type() == cty.String {
+			if prefix := v.Range().StringPrefix(); prefix != "" {
+				return fmt.Sprintf("(a string starting with %s)", displayActualString(prefix))
+			}
+		}
+		return ""
+	}
+	switch v.Type() {
+	case cty.String:
+		return displayActualString(v.AsString())
+	default:
+		text := simpleDisplayValue(v)
+		return redactedText(v.Type().FriendlyName(), text, text)
+	}
+}
+
+// displayActualString returns a representation of the given string that
+// respects the current redaction mode, as a quoted string if redaction is
+// disabled.
+func displayActualString(s string) string {
+	if redaction == redactSummary {
+		return fmt.Sprintf("(string of %d characters)", utf8.RuneCountInString(s))
+	}
+	return redactedText("string", s, strconv.Quote(s))
+}
+
+// formatActualPath is like formatPath, but for a path whose attribute names
+// and keys were taken from the value that an assumption was made about, and
+// so which must respect the current redaction mode.
+func formatActualPath(path cty.Path) string {
+	if redaction == redactNone {
+		return formatPath(path)
+	}
+	var buf strings.Builder
+	for _, step := range path {
+		switch step := step.(type) {
+		case cty.GetAttrStep:
+			buf.WriteString("." + displayActualString(step.Name))
+		case cty.IndexStep:
+			if key := step.Key; key.Type() == cty.String && key.IsKnown() && !key.IsNull() {
+				buf.WriteString("[" + displayActualString(key.AsString()) + "]")
+				continue
+			}
+			buf.WriteString(formatPath(cty.Path{step}))
+		}
+	}
+	return buf.String()
+}
+
+// redactedText returns the given display representation of a value of the
+// given type, redacted as required by the current redaction mode.
+//
+// The raw text is what the fingerprint is calculated from, which for strings
+// is the string itself so that the fingerprint matches the result of
+// Terraform's sha256 function.
+func redactedText(typeName string, raw string, display string) string {
+	switch redaction {
+	case redactNone:
+		return display
+	case redactFingerprint:
+		return fmt.Sprintf("(%s with sha256 %x)", typeName, sha256.Sum256([]byte(raw)))
+	case redactSummary:
+		return "(" + typeName + ")"
+	default:
+		return "(redacted)"
+	}
+}
@@ -0,0 +1,190 @@
+package assume
+
+import (
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestRedaction(t *testing.T) {
+	secretMismatch := []cty.Value{
+		cty.StringVal("hunter2"),
+		cty.StringVal("correct horse"),
+	}
+	secretObjectMismatch := []cty.Value{
+		cty.ObjectVal(map[string]cty.Value{
+			"password": cty.StringVal("hunter2"),
+		}),
+		cty.ObjectVal(map[string]cty.Value{
+			"password": cty.StringVal("correct horse"),
+		}),
+	}
+	prefixConflict := []cty.Value{
+		cty.UnknownVal(cty.String).Refine().StringPrefixFull("arn:aws-cn:").NewValue(),
+		cty.StringVal("arn:aws:"),
+	}
+	numberOutOfRange := []cty.Value{
+		cty.NumberIntVal(5),
+		cty.NumberIntVal(3),
+	}
+
+	t.Run("none", func(t *testing.T) {
+		t.Setenv(redactionEnvVar, "none")
+		testProviderFuncs(t, funcTests{
+			"equal": {
+				"string": {
+					Args:    secretMismatch,
+					WantErr: `the actual value "hunter2" does not match the assumed value`,
+				},
+			},
+		})
+	})
+	t.Run("fingerprint", func(t *testing.T) {
+		t.Setenv(redactionEnvVar, "fingerprint")
+		testProviderFuncs(t, funcTests{
+			"equal": {
+				"string": {
+					Args:    secretMismatch,
+					WantErr: `the actual value (string with sha256 f52fbd32b2b3b86ff88ef6c490628285f482af15ddcb29541f94bcf526a3f6c7) does not match the assumed value`,
+				},
+				"object": {
+					Args:    secretObjectMismatch,
+					WantErr: "the actual value does not match the assumed value:\n  .password: (string with sha256 f52fbd32b2b3b86ff88ef6c490628285f482af15ddcb29541f94bcf526a3f6c7) vs (string with sha256 4104d36f8da2c254349f85836793ebe029e0c957063a34c91c2e9203187b5631)",
+				},
+			},
+			"stringprefix": {
+				"unknown string with conflicting prefix": {
+					Args:    prefixConflict,
+					WantErr: `value already known to start with (string with sha256 228511d6d5b54e805a4788b95b12fd70f7c80ac018b962c71d5ecf8005566473) which conflicts with assumed prefix "arn:aws:"`,
+				},
+			},
+			"numbermax": {
+				"out of range": {
+					Args:    numberOutOfRange,
+					WantErr: `(number with sha256 ef2d127de37b942baad06145e54b0c619a1f22327b2ebbcfbec78f5564afe39d) is greater than assumed maximum 3`,
+				},
+			},
+		})
+	})
+	t.Run("summary", func(t *testing.T) {
+		t.Setenv(redactionEnvVar, "summary")
+		testProviderFuncs(t, funcTests{
+			"equal": {
+				"string": {
+					Args:    secretMismatch,
+					WantErr: `the actual value (string of 7 characters) does not match the assumed value`,
+				},
+				"object": {
+					Args:    secretObjectMismatch,
+					WantErr: "the actual value does not match the assumed value:\n  .password: (string of 7 characters) vs (string of 13 characters)",
+				},
+			},
+			"stringprefix": {
+				"unknown string with conflicting prefix": {
+					Args:    prefixConflict,
+					WantErr: `value already known to start with (string of 11 characters) which conflicts with assumed prefix "arn:aws:"`,
+				},
+			},
+			"numbermax": {
+				"out of range": {
+					Args:    numberOutOfRange,
+					WantErr: `(number) is greater than assumed maximum 3`,
+				},
+			},
+		})
+	})
+	t.Run("all", func(t *testing.T) {
+		t.Setenv(redactionEnvVar, "all")
+		testProviderFuncs(t, funcTests{
+			"equal": {
+				"string": {
+					Args:    secretMismatch,
+					WantErr: `the actual value (redacted) does not match the assumed value`,
+				},
+				"object": {
+					Args:    secretObjectMismatch,
+					WantErr: "the actual value does not match the assumed value:\n  .password: (redacted) vs (redacted)",
+				},
+				"map with unexpected key": {
+					Args: []cty.Value{
+						cty.MapVal(map[string]cty.Value{
+							"a":       cty.StringVal("x"),
+							"hunter2": cty.StringVal("x"),
+						}),
+						cty.MapVal(map[string]cty.Value{
+							"a": cty.StringVal("x"),
+						}),
+					},
+					WantErr: "the actual value does not match the assumed value:\n  value: unexpected key (redacted)",
+				},
+			},
+			"oneof": {
+				"unknown string with conflicting prefix": {
+					Args: []cty.Value{
+						cty.UnknownVal(cty.String).Refine().StringPrefixFull("eu-").NewValue(),
+						cty.TupleVal([]cty.Value{cty.StringVal("us-east-1")}),
+					},
+					WantErr: `the value (a string starting with (redacted)) is not one of the allowed values: (redacted)`,
+				},
+			},
+			"mapkeys": {
+				"unexpected key": {
+					Args: []cty.Value{
+						cty.MapVal(map[string]cty.Value{
+							"a":       cty.StringVal("x"),
+							"hunter2": cty.StringVal("x"),
+						}),
+						cty.TupleVal([]cty.Value{cty.StringVal("a")}),
+					},
+					WantErr: `map has unexpected key (redacted)`,
+				},
+			},
+			"stringtemplate": {
+				"known value with rendered template": {
+					Args: []cty.Value{
+						cty.StringVal("db-hunter2"),
+						cty.StringVal("db-${password}"),
+						cty.ObjectVal(map[string]cty.Value{
+							"password": cty.StringVal("correct horse"),
+						}),
+					},
+					WantErr: `value (redacted) does not match (redacted), rendered from the assumed template "db-${password}"`,
+				},
+			},
+			"notnulldeep": {
+				"map with null element": {
+					Args: []cty.Value{
+						cty.ObjectVal(map[string]cty.Value{
+							"users": cty.MapVal(map[string]cty.Value{
+								"hunter2": cty.NullVal(cty.String),
+							}),
+						}),
+					},
+					WantErr: `.(redacted)[(redacted)] is null`,
+				},
+			},
+			"jsonequal": {
+				"invalid actual value": {
+					Args: []cty.Value{
+						cty.StringVal(`hunter2`),
+						cty.StringVal(`{}`),
+					},
+					WantErr: "the actual value does not match the assumed value after normalizing both as JSON:\n  value: not valid JSON",
+				},
+			},
+		})
+	})
+	t.Run("unrecognized", func(t *testing.T) {
+		// An unrecognized mode is treated as the strictest mode, so that
+		// a typo can't cause values to be shown.
+		t.Setenv(redactionEnvVar, "fingerprnt")
+		testProviderFuncs(t, funcTests{
+			"equal": {
+				"string": {
+					Args:    secretMismatch,
+					WantErr: `the actual value (redacted) does not match the assumed value`,
+				},
+			},
+		})
+	})
+}
@@ -1,10 +1,12 @@
 package assume
 
 import (
+	"errors"
 	"fmt"
 	"math"
 	"math/big"
 	"strconv"
+	"strings"
 
 	"github.com/zclconf/go-cty/cty"
 	"github.com/zclconf/go-cty/cty/convert"
@@ -18,6 +20,9 @@ var notnullFunc = makeRefineFunc(
 	func(args []cty.Value, b *cty.RefinementBuilder) *cty.RefinementBuilder {
 		return b.NotNull()
 	},
+	func(v cty.Value, args []cty.Value) error {
+		return explainNotNull(v)
+	},
 )
 
 var equalFunc = &function.Spec{
@@ -56,26 +61,38 @@ var equalFunc = &function.Spec{
 		if err != nil {
 			return cty.DynamicVal, function.NewArgErrorf(0, "actual value type %s does not match assumed value type %s", args[0].Type().FriendlyName(), args[1].Type().FriendlyName())
 		}
+		// The assumed value may be only partially known, in which case we
+		// check only its known parts. valueDiff ignores unknown values,
+		// but Equals can also detect conflicts with the refinements of an
+		// unknown actual value.
 		eq := actualVal.Equals(args[1])
-		if !eq.IsKnown() || eq.True() {
+		diffs := valueDiff(actualVal, args[1])
+		if (!eq.IsKnown() || eq.True()) && len(diffs) == 0 {
 			// The returned value is always what's given in the second argument,
 			// to make sure that we're definitely consistent in how we handle
 			// unknown vs. known-and-equal first argument. In practice this
 			// shouldn't matter because we know the two values are equal,
 			// but this makes us more robust against bugs in the definition of
 			// "equals".
-			return args[1], nil
+			//
+			// If the second argument has unknown parts then we fill those
+			// from the corresponding parts of the actual value instead.
+			return mergeAssumedValue(actualVal, args[1]), nil
 		}
 		// If we get here then the actual value is known and DOES NOT match
 		// the other provided value, so the assumption was incorrect and so we
 		// fail with an error.
-		var errMsg string
-		if vStr := simpleDisplayValue(args[0]); vStr != "" {
-			errMsg = fmt.Sprintf("the actual value %s does not match the assumed value", vStr)
-		} else {
-			errMsg = "the actual value does not match the assumed value"
+		if vStr := displayActualValue(args[0]); vStr != "" {
+			return cty.DynamicVal, function.NewArgErrorf(0, "the actual value %s does not match the assumed value", vStr)
 		}
-		return cty.DynamicVal, function.NewArgErrorf(0, errMsg)
+		// For more complex values we'll describe the individual differences
+		// between the two values, if we can find any. (We might not find
+		// any if the actual value is unknown but its refinements
+		// contradict the assumed value.)
+		if len(diffs) != 0 {
+			return cty.DynamicVal, function.NewArgErrorf(0, "the actual value does not match the assumed value:\n  %s", strings.Join(diffs, "\n  "))
+		}
+		return cty.DynamicVal, function.NewArgErrorf(0, "the actual value does not match the assumed value")
 	},
 }
 
@@ -87,6 +104,9 @@ var stringprefixFunc = makeRefineFunc(
 		prefix := args[0].AsString()
 		return b.StringPrefix(prefix)
 	},
+	func(v cty.Value, args []cty.Value) error {
+		return explainStringPrefix(v, args[0].AsString())
+	},
 	function.Parameter{
 		Name:        "prefix",
 		Type:        cty.String,
@@ -104,7 +124,76 @@ var maplengthFunc = makeCollectionLengthBoundsFunc(cty.Map, "map")
 var maplengthminFunc = makeCollectionLengthLowerBoundFunc(cty.Map, "map")
 var maplengthmaxFunc = makeCollectionLengthUpperBoundFunc(cty.Map, "map")
 
-func makeRefineFunc(typeConstraint cty.Type, desc string, checkArgs func([]cty.Value) error, refine func(args []cty.Value, b *cty.RefinementBuilder) *cty.RefinementBuilder, params ...function.Parameter) *function.Spec {
+var numberrangeFunc = makeNumberRangeFunc(
+	"Assume that the given number will be within the given range.",
+	func(args []cty.Value, inclusive bool) error {
+		if args[0].GreaterThan(args[1]).True() {
+			return function.NewArgErrorf(1, "must not be less than min")
+		}
+		if !inclusive && args[0].Equals(args[1]).True() {
+			// An exclusive range with equal bounds contains no numbers.
+			return function.NewArgErrorf(1, "must be greater than min when the bounds are exclusive")
+		}
+		return nil
+	},
+	func(args []cty.Value, inclusive bool, b *cty.RefinementBuilder) *cty.RefinementBuilder {
+		return b.NumberRangeLowerBound(args[0], inclusive).NumberRangeUpperBound(args[1], inclusive)
+	},
+	func(v cty.Value, args []cty.Value, inclusive bool) error {
+		return explainNumberRange(v, args[0], args[1], inclusive)
+	},
+	function.Parameter{
+		Name:        "min",
+		Type:        cty.Number,
+		Description: "The lower bound of the range.",
+	},
+	function.Parameter{
+		Name:        "max",
+		Type:        cty.Number,
+		Description: "The upper bound of the range.",
+	},
+)
+
+var numberminFunc = makeNumberRangeFunc(
+	"Assume that the given number will be no less than the given bound.",
+	nil,
+	func(args []cty.Value, inclusive bool, b *cty.RefinementBuilder) *cty.RefinementBuilder {
+		return b.NumberRangeLowerBound(args[0], inclusive)
+	},
+	func(v cty.Value, args []cty.Value, inclusive bool) error {
+		return explainNumberRange(v, args[0], cty.NilVal, inclusive)
+	},
+	function.Parameter{
+		Name:        "min",
+		Type:        cty.Number,
+		Description: "The lower bound of the range.",
+	},
+)
+
+var numbermaxFunc = makeNumberRangeFunc(
+	"Assume that the given number will be no greater than the given bound.",
+	nil,
+	func(args []cty.Value, inclusive bool, b *cty.RefinementBuilder) *cty.RefinementBuilder {
+		return b.NumberRangeUpperBound(args[0], inclusive)
+	},
+	func(v cty.Value, args []cty.Value, inclusive bool) error {
+		return explainNumberRange(v, cty.NilVal, args[0], inclusive)
+	},
+	function.Parameter{
+		Name:        "max",
+		Type:        cty.Number,
+		Description: "The upper bound of the range.",
+	},
+)
+
+// makeRefineFunc builds a function that applies the refinements from the
+// given refine callback to its first argument.
+//
+// If the refinements are not consistent with the given value then the
+// function calls explain to find out why, so that it can return a specific
+// error message. If explain is nil or returns nil then the function returns
+// a generic error message instead.
+func makeRefineFunc(typeConstraint cty.Type, desc string, checkArgs func([]cty.Value) error, refine func(args []cty.Value, b *cty.RefinementBuilder) *cty.RefinementBuilder, explain func(v cty.Value, args []cty.Value) error, params ...function.Parameter) *function.Spec {
 	spec := &function.Spec{
 		Description: desc,
 		Params: []function.Parameter{
@@ -120,9 +209,11 @@ func makeRefineFunc(typeConstraint cty.Type, desc string, checkArgs func([]cty.V
 		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
 			v := args[0]
 			if checkArgs != nil {
-				err := checkArgs(args[1:])
-				if err, ok := err.(*function.ArgError); ok {
-					err.Index++ // to account for the always-present extra "value" argument
+				if err := checkArgs(args[1:]); err != nil {
+					if argErr, ok := err.(function.ArgError); ok {
+						argErr.Index++ // to account for the always-present extra "value" argument
+						err = argErr
+					}
 					return cty.UnknownVal(v.Type()), err
 				}
 			}
@@ -131,7 +222,14 @@ func makeRefineFunc(typeConstraint cty.Type, desc string, checkArgs func([]cty.V
 			}
 			ret, ok := tryApplyRefinement(v, realRefine)
 			if !ok {
-				return cty.UnknownVal(v.Type()), function.NewArgErrorf(0, "assumption was not upheld")
+				var err error
+				if explain != nil {
+					err = explain(v, args[1:])
+				}
+				if err == nil {
+					err = errAssumptionNotUpheld
+				}
+				return cty.UnknownVal(v.Type()), function.NewArgError(0, err)
 			}
 			return ret, nil
 		},
@@ -159,6 +257,11 @@ func makeCollectionLengthBoundsFunc(kind func(cty.Type) cty.Type, noun string) *
 			upper, _ := args[1].AsBigFloat().Int64()
 			return b.CollectionLengthLowerBound(int(lower)).CollectionLengthUpperBound(int(upper))
 		},
+		func(v cty.Value, args []cty.Value) error {
+			lower, _ := args[0].AsBigFloat().Int64()
+			upper, _ := args[1].AsBigFloat().Int64()
+			return explainCollectionLength(v, noun, int(lower), int(upper))
+		},
 		function.Parameter{
 			Name:        "min_length",
 			Type:        cty.Number,
@@ -188,6 +291,10 @@ func makeCollectionLengthLowerBoundFunc(kind func(cty.Type) cty.Type, noun strin
 			bound, _ := args[0].AsBigFloat().Int64()
 			return b.CollectionLengthLowerBound(int(bound))
 		},
+		func(v cty.Value, args []cty.Value) error {
+			bound, _ := args[0].AsBigFloat().Int64()
+			return explainCollectionLength(v, noun, int(bound), -1)
+		},
 		function.Parameter{
 			Name:        "min_length",
 			Type:        cty.Number,
@@ -212,6 +319,10 @@ func makeCollectionLengthUpperBoundFunc(kind func(cty.Type) cty.Type, noun strin
 			bound, _ := args[0].AsBigFloat().Int64()
 			return b.CollectionLengthUpperBound(int(bound))
 		},
+		func(v cty.Value, args []cty.Value) error {
+			bound, _ := args[0].AsBigFloat().Int64()
+			return explainCollectionLength(v, noun, -1, int(bound))
+		},
 		function.Parameter{
 			Name:        "max_length",
 			Type:        cty.Number,
@@ -220,6 +331,46 @@ func makeCollectionLengthUpperBoundFunc(kind func(cty.Type) cty.Type, noun strin
 	)
 }
 
+// makeNumberRangeFunc wraps makeRefineFunc to add an optional trailing
+// "inclusive" argument, which defaults to true when not specified.
+//
+// The checkArgs, refine, and explain callbacks receive only the arguments corresponding
+// to the given params, with the inclusive flag already decoded.
+func makeNumberRangeFunc(desc string, checkArgs func(args []cty.Value, inclusive bool) error, refine func(args []cty.Value, inclusive bool, b *cty.RefinementBuilder) *cty.RefinementBuilder, explain func(v cty.Value, args []cty.Value, inclusive bool) error, params ...function.Parameter) *function.Spec {
+	inclusiveArg := func(args []cty.Value) bool {
+		if len(args) <= len(params) {
+			return true // inclusive by default
+		}
+		return args[len(params)].True()
+	}
+	spec := makeRefineFunc(
+		cty.Number,
+		desc,
+		func(args []cty.Value) error {
+			if len(args) > len(params)+1 {
+				return function.NewArgErrorf(len(params)+1, "too many arguments; only one inclusive flag is allowed")
+			}
+			if checkArgs != nil {
+				return checkArgs(args[:len(params)], inclusiveArg(args))
+			}
+			return nil
+		},
+		func(args []cty.Value, b *cty.RefinementBuilder) *cty.RefinementBuilder {
+			return refine(args[:len(params)], inclusiveArg(args), b)
+		},
+		func(v cty.Value, args []cty.Value) error {
+			return explain(v, args[:len(params)], inclusiveArg(args))
+		},
+		params...,
+	)
+	spec.VarParam = &function.Parameter{
+		Name:        "inclusive",
+		Type:        cty.Bool,
+		Description: "Whether the given bounds are themselves included in the range. Defaults to true.",
+	}
+	return spec
+}
+
 func tryApplyRefinement(v cty.Value, refine func(b *cty.RefinementBuilder) *cty.RefinementBuilder) (result cty.Value, ok bool) {
 	defer func() {
 		if bad := recover(); bad != nil {
@@ -234,6 +385,180 @@ func tryApplyRefinement(v cty.Value, refine func(b *cty.RefinementBuilder) *cty.
 	return v.RefineWith(refine), true
 }
 
+// errAssumptionNotUpheld is the generic error returned when a refinement
+// fails and we have no more specific explanation for why.
+var errAssumptionNotUpheld = errors.New("assumption was not upheld")
+
+// explainNotNull returns an error describing why the given value cannot be
+// refined as not null, or nil if there is no specific explanation.
+func explainNotNull(v cty.Value) error {
+	if v.IsKnown() && v.IsNull() {
+		return fmt.Errorf("value is null but was assumed to never be null")
+	}
+	return nil
+}
+
+// explainStringPrefix returns an error describing why the given value cannot
+// be refined as having the given prefix, or nil if there is no specific
+// explanation.
+func explainStringPrefix(v cty.Value, prefix string) error {
+	if v.IsKnown() {
+		if v.IsNull() {
+			return nil // a null string is consistent with any prefix
+		}
+		return fmt.Errorf("value %s does not start with assumed prefix %q", displayActualValue(v), prefix)
+	}
+	if have := v.Range().StringPrefix(); have != "" {
+		return fmt.Errorf("value already known to start with %s which conflicts with assumed prefix %q", displayActualString(have), prefix)
+	}
+	return nil
+}
+
+// refineStringPrefix returns the given string value refined as starting with
+// the given prefix, and also as not null if notNull is set. An empty prefix
+// adds no prefix refinement.
+//
+// If the value can't be refined that way then the result is an error about
+// the first argument, explaining the conflict and then where the prefix came
+// from using the given phrase, like "implied by the assumed range 10.0.0.0/8".
+func refineStringPrefix(v cty.Value, prefix string, notNull bool, source string) (cty.Value, error) {
+	ret, ok := tryApplyRefinement(v, func(b *cty.RefinementBuilder) *cty.RefinementBuilder {
+		if notNull {
+			b = b.NotNull()
+		}
+		if prefix != "" {
+			b = b.StringPrefix(prefix)
+		}
+		return b
+	})
+	if !ok {
+		err := explainStringPrefix(v, prefix)
+		if err == nil {
+			err = errAssumptionNotUpheld
+		}
+		return cty.UnknownVal(cty.String), function.NewArgErrorf(0, "%s, %s", err, source)
+	}
+	return ret, nil
+}
+
+// explainCollectionLength returns an error describing why the given value
+// cannot be refined as having a length between the given bounds, or nil if
+// there is no specific explanation.
+//
+// Either bound can be negative to represent that there is no bound in
+// that direction.
+func explainCollectionLength(v cty.Value, noun string, min, max int) error {
+	var assumed string
+	switch {
+	case min == max:
+		assumed = "exactly " + elementCount(min)
+	case min >= 0 && max >= 0:
+		assumed = fmt.Sprintf("between %d and %d elements", min, max)
+	case min >= 0:
+		assumed = "at least " + elementCount(min)
+	default:
+		assumed = "at most " + elementCount(max)
+	}
+
+	if v.IsKnown() {
+		if v.IsNull() {
+			return fmt.Errorf("%s is null but was assumed to have %s", noun, assumed)
+		}
+		// The number of elements is already clear from the first part of
+		// the message, so we describe only the bound that was violated.
+		length := v.LengthInt()
+		switch {
+		case min == max && length != min:
+			assumed = fmt.Sprintf("exactly %d", min)
+		case min >= 0 && length < min:
+			assumed = fmt.Sprintf("at least %d", min)
+		case max >= 0 && length > max:
+			assumed = fmt.Sprintf("at most %d", max)
+		default:
+			return nil
+		}
+		return fmt.Errorf("%s has %s but was assumed to have %s", noun, elementCount(length), assumed)
+	}
+
+	rng := v.Range()
+	if max >= 0 && rng.LengthLowerBound() > max {
+		return fmt.Errorf("%s already known to have at least %s which conflicts with assumed maximum length %d", noun, elementCount(rng.LengthLowerBound()), max)
+	}
+	if min >= 0 && rng.LengthUpperBound() < min {
+		return fmt.Errorf("%s already known to have at most %s which conflicts with assumed minimum length %d", noun, elementCount(rng.LengthUpperBound()), min)
+	}
+	return nil
+}
+
+func elementCount(n int) string {
+	if n == 1 {
+		return "1 element"
+	}
+	return fmt.Sprintf("%d elements", n)
+}
+
+// explainNumberRange returns an error describing why the given value cannot
+// be refined as being within the given bounds, or nil if there is no
+// specific explanation.
+//
+// Either bound can be cty.NilVal to represent that there is no bound in
+// that direction.
+func explainNumberRange(v cty.Value, min, max cty.Value, inclusive bool) error {
+	minDesc, maxDesc := "minimum", "maximum"
+	tooLow, tooHigh := "is less than", "is greater than"
+	if !inclusive {
+		minDesc, maxDesc = "exclusive minimum", "exclusive maximum"
+		tooLow, tooHigh = "is not greater than", "is not less than"
+	}
+
+	if v.IsKnown() {
+		if v.IsNull() {
+			return fmt.Errorf("value is null but was assumed to be a number")
+		}
+		if min != cty.NilVal {
+			if (inclusive && v.LessThan(min).True()) || (!inclusive && v.LessThanOrEqualTo(min).True()) {
+				return fmt.Errorf("%s %s assumed %s %s", displayActualValue(v), tooLow, minDesc, simpleDisplayValue(min))
+			}
+		}
+		if max != cty.NilVal {
+			if (inclusive && v.GreaterThan(max).True()) || (!inclusive && v.GreaterThanOrEqualTo(max).True()) {
+				return fmt.Errorf("%s %s assumed %s %s", displayActualValue(v), tooHigh, maxDesc, simpleDisplayValue(max))
+			}
+		}
+		return nil
+	}
+
+	rng := v.Range()
+	if max != cty.NilVal {
+		if lower, lowerInc := rng.NumberLowerBound(); lower.IsKnown() && lower != cty.NegativeInfinity {
+			if lower.GreaterThan(max).True() || (lower.Equals(max).True() && !(lowerInc && inclusive)) {
+				return fmt.Errorf("value already known to be %s which conflicts with assumed %s %s", describeNumberBound(">", lower, lowerInc), maxDesc, simpleDisplayValue(max))
+			}
+		}
+	}
+	if min != cty.NilVal {
+		if upper, upperInc := rng.NumberUpperBound(); upper.IsKnown() && upper != cty.PositiveInfinity {
+			if upper.LessThan(min).True() || (upper.Equals(min).True() && !(upperInc && inclusive)) {
+				return fmt.Errorf("value already known to be %s which conflicts with assumed %s %s", describeNumberBound("<", upper, upperInc), minDesc, simpleDisplayValue(min))
+			}
+		}
+	}
+	return nil
+}
+
+func describeNumberBound(op string, bound cty.Value, inclusive bool) string {
+	switch {
+	case op == ">" && inclusive:
+		return "at least " + simpleDisplayValue(bound)
+	case op == ">":
+		return "greater than " + simpleDisplayValue(bound)
+	case inclusive:
+		return "at most " + simpleDisplayValue(bound)
+	default:
+		return "less than " + simpleDisplayValue(bound)
+	}
+}
+
 // simpleDisplayValue returns a relatively-compact representation of the given
 // value to show in the UI if possible, or an empty string if the given value
 // is too complicated for that.
@@ -268,3 +593,82 @@ func simpleDisplayValue(v cty.Value) string {
 		return ""
 	}
 }
+
+// formatPath returns a compact string representation of the given path,
+// using syntax similar to Terraform's traversal syntax, such as
+// `.network.subnets[0]`.
+func formatPath(path cty.Path) string {
+	var buf strings.Builder
+	for _, step := range path {
+		switch step := step.(type) {
+		case cty.GetAttrStep:
+			buf.WriteString("." + step.Name)
+		case cty.IndexStep:
+			key := step.Key
+			switch {
+			case !key.IsKnown() || key.IsNull():
+				buf.WriteString("[...]")
+			case key.Type() == cty.String:
+				buf.WriteString("[" + strconv.Quote(key.AsString()) + "]")
+			case key.Type() == cty.Number:
+				buf.WriteString("[" + key.AsBigFloat().Text('f', -1) + "]")
+			default:
+				// Set elements are identified by their own values, which
+				// we can't represent compactly.
+				buf.WriteString("[...]")
+			}
+		}
+	}
+	return buf.String()
+}
+
+// mergeAssumedValue returns the given assumed value with any of its unknown
+// parts replaced by the corresponding parts of the given actual value.
+//
+// The caller must already have checked that the known parts of the two
+// values are equal, and so in particular that any known collections in
+// the two values have the same keys or lengths.
+func mergeAssumedValue(actual, assumed cty.Value) cty.Value {
+	if assumed.IsWhollyKnown() {
+		return assumed
+	}
+	if !assumed.IsKnown() {
+		return actual
+	}
+	if !actual.IsKnown() {
+		// We can't extract any parts of an unknown value, so the assumed
+		// value is the best we can do.
+		return assumed
+	}
+
+	ty := assumed.Type()
+	switch {
+	case ty.IsObjectType():
+		attrs := make(map[string]cty.Value, len(ty.AttributeTypes()))
+		for name := range ty.AttributeTypes() {
+			attrs[name] = mergeAssumedValue(actual.GetAttr(name), assumed.GetAttr(name))
+		}
+		return cty.ObjectVal(attrs)
+	case ty.IsMapType():
+		elems := assumed.AsValueMap()
+		for k, elem := range elems {
+			elems[k] = mergeAssumedValue(actual.Index(cty.StringVal(k)), elem)
+		}
+		return cty.MapVal(elems)
+	case ty.IsListType() || ty.IsTupleType():
+		elems := assumed.AsValueSlice()
+		for i, elem := range elems {
+			elems[i] = mergeAssumedValue(actual.Index(cty.NumberIntVal(int64(i))), elem)
+		}
+		if ty.IsTupleType() {
+			return cty.TupleVal(elems)
+		}
+		return cty.ListVal(elems)
+	default:
+		// Set elements have no identity other than their values, so we
+		// can't correlate unknown elements of the assumed set with the
+		// elements of the actual set. We'll use the actual set instead,
+		// since we already know it's consistent with the assumed set.
+		return actual
+	}
+}
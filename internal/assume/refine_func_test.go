@@ -1,19 +1,27 @@
 package assume
 
 import (
+	"errors"
+	"fmt"
+	"math"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/zclconf/go-cty-debug/ctydebug"
 	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
 )
 
+// funcTests is a table of test cases for provider functions, keyed first by
+// function name and then by test case name.
+type funcTests map[string]map[string]struct {
+	Args    []cty.Value
+	Want    cty.Value
+	WantErr string
+}
+
 func TestRefineFuncs(t *testing.T) {
-	tests := map[string]map[string]struct {
-		Args    []cty.Value
-		Want    cty.Value
-		WantErr string
-	}{
+	tests := funcTests{
 
 		"notnull": {
 			"dynamicval": {
@@ -38,7 +46,7 @@ func TestRefineFuncs(t *testing.T) {
 				Args: []cty.Value{
 					cty.NullVal(cty.String),
 				},
-				WantErr: `assumption was not upheld`,
+				WantErr: "value is null but was assumed to never be null",
 			},
 		},
 
@@ -90,7 +98,7 @@ func TestRefineFuncs(t *testing.T) {
 					cty.MapVal(map[string]cty.Value{"greeting": cty.StringVal("howdy")}),
 					cty.ObjectVal(map[string]cty.Value{"greeting": cty.StringVal("hello")}),
 				},
-				WantErr: `the actual value does not match the assumed value`,
+				WantErr: "the actual value does not match the assumed value:\n  .greeting: \"howdy\" vs \"hello\"",
 			},
 			"mismatching types with unknown value, convertable": {
 				Args: []cty.Value{
@@ -135,7 +143,188 @@ func TestRefineFuncs(t *testing.T) {
 					cty.ListVal([]cty.Value{cty.StringVal("a"), cty.UnknownVal(cty.String)}),
 					cty.ListVal([]cty.Value{cty.StringVal("not a"), cty.StringVal("b")}),
 				},
-				WantErr: `the actual value does not match the assumed value`,
+				WantErr: "the actual value does not match the assumed value:\n  [0]: \"a\" vs \"not a\"",
+			},
+			"nested object with differing string": {
+				Args: []cty.Value{
+					cty.ObjectVal(map[string]cty.Value{
+						"Statement": cty.TupleVal([]cty.Value{
+							cty.ObjectVal(map[string]cty.Value{
+								"Effect": cty.StringVal("Allow"),
+								"Principal": cty.ObjectVal(map[string]cty.Value{
+									"AWS": cty.StringVal("arn:aws:iam::123456789012:role/service-role/example-x"),
+								}),
+							}),
+						}),
+					}),
+					cty.ObjectVal(map[string]cty.Value{
+						"Statement": cty.TupleVal([]cty.Value{
+							cty.ObjectVal(map[string]cty.Value{
+								"Effect": cty.StringVal("Allow"),
+								"Principal": cty.ObjectVal(map[string]cty.Value{
+									"AWS": cty.StringVal("arn:aws:iam::123456789012:role/service-role/example-y"),
+								}),
+							}),
+						}),
+					}),
+				},
+				WantErr: "the actual value does not match the assumed value:\n  .Statement[0].Principal.AWS: \"arn:aws:iam::1234567...rvice-role/example-x\" vs \"arn:aws:iam::1234567...rvice-role/example-y\"",
+			},
+			"map with added and missing keys": {
+				Args: []cty.Value{
+					cty.MapVal(map[string]cty.Value{
+						"a": cty.StringVal("a"),
+						"b": cty.StringVal("b"),
+						"d": cty.StringVal("d"),
+					}),
+					cty.MapVal(map[string]cty.Value{
+						"a": cty.StringVal("a"),
+						"b": cty.StringVal("B"),
+						"c": cty.StringVal("c"),
+					}),
+				},
+				WantErr: "the actual value does not match the assumed value:\n  [\"b\"]: \"b\" vs \"B\"\n  value: missing key \"c\"\n  value: unexpected key \"d\"",
+			},
+			"list with different length": {
+				Args: []cty.Value{
+					cty.ListVal([]cty.Value{cty.StringVal("a"), cty.StringVal("c")}),
+					cty.ListVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b"), cty.StringVal("c")}),
+				},
+				WantErr: "the actual value does not match the assumed value:\n  value: has 2 elements but assumed value has 3\n  [1]: \"c\" vs \"b\"",
+			},
+			"set with differing elements": {
+				Args: []cty.Value{
+					cty.ObjectVal(map[string]cty.Value{
+						"ids": cty.SetVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b")}),
+					}),
+					cty.ObjectVal(map[string]cty.Value{
+						"ids": cty.SetVal([]cty.Value{cty.StringVal("a"), cty.StringVal("c")}),
+					}),
+				},
+				WantErr: "the actual value does not match the assumed value:\n  .ids: unexpected element \"b\"\n  .ids: missing element \"c\"",
+			},
+			"set with unknown assumed element": {
+				Args: []cty.Value{
+					cty.SetVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b")}),
+					cty.SetVal([]cty.Value{cty.StringVal("a"), cty.UnknownVal(cty.String)}),
+				},
+				Want: cty.SetVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b")}),
+			},
+			"null nested object": {
+				Args: []cty.Value{
+					cty.ObjectVal(map[string]cty.Value{
+						"inner": cty.NullVal(cty.Object(map[string]cty.Type{"a": cty.String})),
+					}),
+					cty.ObjectVal(map[string]cty.Value{
+						"inner": cty.ObjectVal(map[string]cty.Value{"a": cty.StringVal("a")}),
+					}),
+				},
+				WantErr: "the actual value does not match the assumed value:\n  .inner: null vs object with attributes a",
+			},
+			"many differences": {
+				Args: []cty.Value{
+					cty.ListVal([]cty.Value{
+						cty.NumberIntVal(0), cty.NumberIntVal(1), cty.NumberIntVal(2), cty.NumberIntVal(3),
+						cty.NumberIntVal(4), cty.NumberIntVal(5), cty.NumberIntVal(6), cty.NumberIntVal(7),
+						cty.NumberIntVal(8), cty.NumberIntVal(9), cty.NumberIntVal(10), cty.NumberIntVal(11),
+					}),
+					cty.ListVal([]cty.Value{
+						cty.NumberIntVal(10), cty.NumberIntVal(11), cty.NumberIntVal(12), cty.NumberIntVal(13),
+						cty.NumberIntVal(14), cty.NumberIntVal(15), cty.NumberIntVal(16), cty.NumberIntVal(17),
+						cty.NumberIntVal(18), cty.NumberIntVal(19), cty.NumberIntVal(20), cty.NumberIntVal(21),
+					}),
+				},
+				WantErr: "the actual value does not match the assumed value:\n  [0]: 0 vs 10\n  [1]: 1 vs 11\n  [2]: 2 vs 12\n  [3]: 3 vs 13\n  [4]: 4 vs 14\n  [5]: 5 vs 15\n  [6]: 6 vs 16\n  [7]: 7 vs 17\n  [8]: 8 vs 18\n  [9]: 9 vs 19\n  ...and 2 more differences",
+			},
+			"unknown assumed value": {
+				Args: []cty.Value{
+					cty.StringVal("hi"),
+					cty.UnknownVal(cty.String),
+				},
+				WantErr: "the second argument must be something known during the planning phase",
+			},
+			"partially-unknown assumed value with unknown actual value": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.Object(map[string]cty.Type{
+						"id":   cty.String,
+						"name": cty.String,
+					})),
+					cty.ObjectVal(map[string]cty.Value{
+						"id":   cty.UnknownVal(cty.String),
+						"name": cty.StringVal("example"),
+					}),
+				},
+				Want: cty.ObjectVal(map[string]cty.Value{
+					"id":   cty.UnknownVal(cty.String),
+					"name": cty.StringVal("example"),
+				}),
+			},
+			"partially-unknown assumed value with known actual value": {
+				Args: []cty.Value{
+					cty.ObjectVal(map[string]cty.Value{
+						"id":   cty.StringVal("i-abc123"),
+						"name": cty.StringVal("example"),
+						"tags": cty.MapVal(map[string]cty.Value{
+							"Name":  cty.StringVal("example"),
+							"Owner": cty.StringVal("platform"),
+						}),
+						"zones": cty.ListVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b")}),
+					}),
+					cty.ObjectVal(map[string]cty.Value{
+						"id":   cty.UnknownVal(cty.String),
+						"name": cty.StringVal("example"),
+						"tags": cty.MapVal(map[string]cty.Value{
+							"Name":  cty.StringVal("example"),
+							"Owner": cty.UnknownVal(cty.String),
+						}),
+						"zones": cty.ListVal([]cty.Value{cty.StringVal("a"), cty.UnknownVal(cty.String)}),
+					}),
+				},
+				Want: cty.ObjectVal(map[string]cty.Value{
+					"id":   cty.StringVal("i-abc123"),
+					"name": cty.StringVal("example"),
+					"tags": cty.MapVal(map[string]cty.Value{
+						"Name":  cty.StringVal("example"),
+						"Owner": cty.StringVal("platform"),
+					}),
+					"zones": cty.ListVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b")}),
+				}),
+			},
+			"partially-unknown assumed value with partially-unknown actual value": {
+				Args: []cty.Value{
+					cty.ObjectVal(map[string]cty.Value{
+						"id":   cty.StringVal("i-abc123"),
+						"name": cty.UnknownVal(cty.String),
+					}),
+					cty.ObjectVal(map[string]cty.Value{
+						"id":   cty.UnknownVal(cty.String),
+						"name": cty.StringVal("example"),
+					}),
+				},
+				Want: cty.ObjectVal(map[string]cty.Value{
+					"id":   cty.StringVal("i-abc123"),
+					"name": cty.StringVal("example"),
+				}),
+			},
+			"partially-unknown assumed value with incorrect known part": {
+				Args: []cty.Value{
+					cty.ObjectVal(map[string]cty.Value{
+						"id":   cty.StringVal("i-abc123"),
+						"name": cty.StringVal("other"),
+					}),
+					cty.ObjectVal(map[string]cty.Value{
+						"id":   cty.UnknownVal(cty.String),
+						"name": cty.StringVal("example"),
+					}),
+				},
+				WantErr: "the actual value does not match the assumed value:\n  .name: \"other\" vs \"example\"",
+			},
+			"partially-unknown assumed value with incorrect known part after unknown": {
+				Args: []cty.Value{
+					cty.TupleVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b")}),
+					cty.TupleVal([]cty.Value{cty.UnknownVal(cty.String), cty.StringVal("c")}),
+				},
+				WantErr: "the actual value does not match the assumed value:\n  [1]: \"b\" vs \"c\"",
 			},
 		},
 
@@ -189,7 +378,14 @@ func TestRefineFuncs(t *testing.T) {
 					cty.StringVal("bar-baz"),
 					cty.StringVal("foo-"),
 				},
-				WantErr: `assumption was not upheld`,
+				WantErr: "value \"bar-baz\" does not start with assumed prefix \"foo-\"",
+			},
+			"unknown string with conflicting prefix": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String).Refine().StringPrefixFull("arn:aws-cn:").NewValue(),
+					cty.StringVal("arn:aws:"),
+				},
+				WantErr: "value already known to start with \"arn:aws-cn:\" which conflicts with assumed prefix \"arn:aws:\"",
 			},
 		},
 
@@ -227,7 +423,15 @@ func TestRefineFuncs(t *testing.T) {
 					cty.NumberIntVal(1),
 					cty.NumberIntVal(2),
 				},
-				WantErr: "assumption was not upheld",
+				WantErr: "list has 0 elements but was assumed to have at least 1",
+			},
+			"unknown list with conflicting length": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.List(cty.String)).Refine().CollectionLengthLowerBound(5).NewValue(),
+					cty.NumberIntVal(1),
+					cty.NumberIntVal(3),
+				},
+				WantErr: "list already known to have at least 5 elements which conflicts with assumed maximum length 3",
 			},
 		},
 		"listlengthmin": {
@@ -259,7 +463,7 @@ func TestRefineFuncs(t *testing.T) {
 					cty.ListValEmpty(cty.String),
 					cty.NumberIntVal(1),
 				},
-				WantErr: "assumption was not upheld",
+				WantErr: "list has 0 elements but was assumed to have at least 1",
 			},
 		},
 		"listlengthmax": {
@@ -291,7 +495,20 @@ func TestRefineFuncs(t *testing.T) {
 					cty.ListVal([]cty.Value{cty.True, cty.True}),
 					cty.NumberIntVal(1),
 				},
-				WantErr: "assumption was not upheld",
+				WantErr: "list has 2 elements but was assumed to have at most 1",
+			},
+			"known list that is too long": {
+				Args: []cty.Value{
+					cty.ListVal([]cty.Value{
+						cty.StringVal("a"),
+						cty.StringVal("b"),
+						cty.StringVal("c"),
+						cty.StringVal("d"),
+						cty.StringVal("e"),
+					}),
+					cty.NumberIntVal(3),
+				},
+				WantErr: "list has 5 elements but was assumed to have at most 3",
 			},
 		},
 
@@ -329,7 +546,7 @@ func TestRefineFuncs(t *testing.T) {
 					cty.NumberIntVal(1),
 					cty.NumberIntVal(2),
 				},
-				WantErr: "assumption was not upheld",
+				WantErr: "set has 0 elements but was assumed to have at least 1",
 			},
 		},
 		"setlengthmin": {
@@ -361,7 +578,14 @@ func TestRefineFuncs(t *testing.T) {
 					cty.SetValEmpty(cty.String),
 					cty.NumberIntVal(1),
 				},
-				WantErr: "assumption was not upheld",
+				WantErr: "set has 0 elements but was assumed to have at least 1",
+			},
+			"unknown set with conflicting length": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.Set(cty.String)).Refine().CollectionLengthUpperBound(1).NewValue(),
+					cty.NumberIntVal(2),
+				},
+				WantErr: "set already known to have at most 1 element which conflicts with assumed minimum length 2",
 			},
 		},
 		"setlengthmax": {
@@ -393,7 +617,7 @@ func TestRefineFuncs(t *testing.T) {
 					cty.SetVal([]cty.Value{cty.Zero, cty.NumberIntVal(1)}),
 					cty.NumberIntVal(1),
 				},
-				WantErr: "assumption was not upheld",
+				WantErr: "set has 2 elements but was assumed to have at most 1",
 			},
 		},
 
@@ -431,7 +655,7 @@ func TestRefineFuncs(t *testing.T) {
 					cty.NumberIntVal(1),
 					cty.NumberIntVal(2),
 				},
-				WantErr: "assumption was not upheld",
+				WantErr: "map has 0 elements but was assumed to have at least 1",
 			},
 		},
 		"maplengthmin": {
@@ -463,7 +687,7 @@ func TestRefineFuncs(t *testing.T) {
 					cty.MapValEmpty(cty.String),
 					cty.NumberIntVal(1),
 				},
-				WantErr: "assumption was not upheld",
+				WantErr: "map has 0 elements but was assumed to have at least 1",
 			},
 		},
 		"maplengthmax": {
@@ -495,11 +719,234 @@ func TestRefineFuncs(t *testing.T) {
 					cty.MapVal(map[string]cty.Value{"a": cty.Zero, "b": cty.Zero}),
 					cty.NumberIntVal(1),
 				},
-				WantErr: "assumption was not upheld",
+				WantErr: "map has 2 elements but was assumed to have at most 1",
 			},
 		},
+
+		"numberrange": {
+			"dynamicval": {
+				Args: []cty.Value{
+					cty.DynamicVal,
+					cty.NumberIntVal(1),
+					cty.NumberIntVal(5),
+				},
+				Want: cty.DynamicVal,
+			},
+			"unknown number": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.Number),
+					cty.NumberIntVal(1),
+					cty.NumberIntVal(5),
+				},
+				Want: cty.UnknownVal(cty.Number).Refine().
+					NumberRangeInclusive(cty.NumberIntVal(1), cty.NumberIntVal(5)).
+					NewValue(),
+			},
+			"unknown number, exclusive": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.Number),
+					cty.NumberIntVal(1),
+					cty.NumberIntVal(5),
+					cty.False,
+				},
+				Want: cty.UnknownVal(cty.Number).Refine().
+					NumberRangeLowerBound(cty.NumberIntVal(1), false).
+					NumberRangeUpperBound(cty.NumberIntVal(5), false).
+					NewValue(),
+			},
+			"known number in range": {
+				Args: []cty.Value{
+					cty.NumberIntVal(5),
+					cty.NumberIntVal(1),
+					cty.NumberIntVal(5),
+				},
+				Want: cty.NumberIntVal(5),
+			},
+			"known number on exclusive bound": {
+				Args: []cty.Value{
+					cty.NumberIntVal(5),
+					cty.NumberIntVal(1),
+					cty.NumberIntVal(5),
+					cty.False,
+				},
+				WantErr: "5 is not less than assumed exclusive maximum 5",
+			},
+			"known number out of range": {
+				Args: []cty.Value{
+					cty.NumberIntVal(0),
+					cty.NumberIntVal(1),
+					cty.NumberIntVal(5),
+				},
+				WantErr: "0 is less than assumed minimum 1",
+			},
+			"inverted bounds": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.Number),
+					cty.NumberIntVal(5),
+					cty.NumberIntVal(1),
+				},
+				WantErr: "must not be less than min",
+			},
+			"equal exclusive bounds": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.Number),
+					cty.NumberIntVal(5),
+					cty.NumberIntVal(5),
+					cty.False,
+				},
+				WantErr: "must be greater than min when the bounds are exclusive",
+			},
+			"equal inclusive bounds": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.Number),
+					cty.NumberIntVal(5),
+					cty.NumberIntVal(5),
+				},
+				Want: cty.UnknownVal(cty.Number).Refine().
+					NumberRangeInclusive(cty.NumberIntVal(5), cty.NumberIntVal(5)).
+					NewValue(),
+			},
+			"too many inclusive flags": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.Number),
+					cty.NumberIntVal(1),
+					cty.NumberIntVal(5),
+					cty.True,
+					cty.True,
+				},
+				WantErr: "too many arguments; only one inclusive flag is allowed",
+			},
+			"unknown number with conflicting lower bound": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.Number).Refine().NumberRangeLowerBound(cty.NumberIntVal(5), true).NewValue(),
+					cty.NumberIntVal(1),
+					cty.NumberIntVal(3),
+				},
+				WantErr: "value already known to be at least 5 which conflicts with assumed maximum 3",
+			},
+			"unknown number with conflicting upper bound": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.Number).Refine().NumberRangeUpperBound(cty.NumberIntVal(1), false).NewValue(),
+					cty.NumberIntVal(1),
+					cty.NumberIntVal(3),
+				},
+				WantErr: "value already known to be less than 1 which conflicts with assumed minimum 1",
+			},
+		},
+		"numbermin": {
+			"unknown number": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.Number),
+					cty.Zero,
+				},
+				Want: cty.UnknownVal(cty.Number).Refine().
+					NumberRangeLowerBound(cty.Zero, true).
+					NewValue(),
+			},
+			"unknown number, exclusive": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.Number),
+					cty.Zero,
+					cty.False,
+				},
+				Want: cty.UnknownVal(cty.Number).Refine().
+					NumberRangeLowerBound(cty.Zero, false).
+					NewValue(),
+			},
+			"known number in range": {
+				Args: []cty.Value{
+					cty.Zero,
+					cty.Zero,
+				},
+				Want: cty.Zero,
+			},
+			"known number out of range": {
+				Args: []cty.Value{
+					cty.Zero,
+					cty.Zero,
+					cty.False,
+				},
+				WantErr: "0 is not greater than assumed exclusive minimum 0",
+			},
+			"null number": {
+				Args: []cty.Value{
+					cty.NullVal(cty.Number),
+					cty.Zero,
+				},
+				WantErr: "value is null but was assumed to be a number",
+			},
+		},
+		"numbermax": {
+			"unknown number": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.Number),
+					cty.NumberIntVal(10),
+				},
+				Want: cty.UnknownVal(cty.Number).Refine().
+					NumberRangeUpperBound(cty.NumberIntVal(10), true).
+					NewValue(),
+			},
+			"known number in range": {
+				Args: []cty.Value{
+					cty.NumberFloatVal(9.5),
+					cty.NumberIntVal(10),
+					cty.False,
+				},
+				Want: cty.NumberFloatVal(9.5),
+			},
+			"known number out of range": {
+				Args: []cty.Value{
+					cty.NumberIntVal(11),
+					cty.NumberIntVal(10),
+				},
+				WantErr: "11 is greater than assumed maximum 10",
+			},
+		},
+	}
+
+	testProviderFuncs(t, tests)
+}
+
+func TestRefineFuncArgErrorIndex(t *testing.T) {
+	// The errors from a refine function's extra arguments must refer to
+	// those arguments, which come after the value argument.
+	tests := map[string]struct {
+		Args      []cty.Value
+		WantIndex int
+	}{
+		"invalid minimum": {
+			Args:      []cty.Value{cty.UnknownVal(cty.List(cty.String)), cty.NumberFloatVal(1.5), cty.NumberIntVal(3)},
+			WantIndex: 1,
+		},
+		"invalid maximum": {
+			Args:      []cty.Value{cty.UnknownVal(cty.List(cty.String)), cty.NumberIntVal(1), cty.NumberFloatVal(2.5)},
+			WantIndex: 2,
+		},
 	}
 
+	f := NewProvider().CallStub("listlength")
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := f(test.Args...)
+			var argErr function.ArgError
+			if !errors.As(err, &argErr) {
+				t.Fatalf("wrong error type %T; want function.ArgError\nerror: %s", err, err)
+			}
+			if got, want := argErr.Index, test.WantIndex; got != want {
+				t.Errorf("wrong argument index %d; want %d", got, want)
+			}
+			if got, want := argErr.Error(), fmt.Sprintf("must be a whole number between 0 and %d", math.MaxInt); got != want {
+				t.Errorf("wrong error\ngot:  %s\nwant: %s", got, want)
+			}
+		})
+	}
+}
+
+// testProviderFuncs runs each of the given test cases against the function
+// of the given name in the provider returned by [NewProvider].
+func testProviderFuncs(t *testing.T, tests funcTests) {
+	t.Helper()
+
 	p := NewProvider()
 	for funcName, funcTests := range tests {
 		t.Run(funcName, func(t *testing.T) {
@@ -0,0 +1,256 @@
+package assume
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+var mapkeysFunc = &function.Spec{
+	Description: "Assume that the given map will have exactly the given keys.",
+	Params: []function.Parameter{
+		{
+			Name:         "value",
+			Type:         cty.Map(cty.DynamicPseudoType),
+			Description:  "The value to make the assumption about.",
+			AllowNull:    true,
+			AllowUnknown: true,
+		},
+		{
+			Name:             "keys",
+			Type:             cty.DynamicPseudoType,
+			Description:      "A collection of the keys to assume, or a map or object whose keys or attribute names should be assumed.",
+			AllowDynamicType: true,
+		},
+	},
+	Type: func(args []cty.Value) (cty.Type, error) {
+		return args[0].Type(), nil
+	},
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		v := args[0]
+		keys, err := assumedMapKeys(args[1])
+		if err != nil {
+			return cty.UnknownVal(retType), function.NewArgError(1, err)
+		}
+		if keys == nil {
+			// The keys argument is only partially known, so we can't
+			// make any assumption yet.
+			return cty.UnknownVal(retType), nil
+		}
+
+		if v.IsKnown() {
+			if v.IsNull() {
+				return cty.UnknownVal(retType), function.NewArgErrorf(0, "value must not be null")
+			}
+			var missing, extra []string
+			for k := range keys {
+				if !v.HasIndex(cty.StringVal(k)).True() {
+					missing = append(missing, k)
+				}
+			}
+			for it := v.ElementIterator(); it.Next(); {
+				k, _ := it.Element()
+				if _, ok := keys[k.AsString()]; !ok {
+					extra = append(extra, k.AsString())
+				}
+			}
+			var problems []string
+			if len(missing) != 0 {
+				problems = append(problems, "map is missing "+keyList(missing, strconv.Quote))
+			}
+			if len(extra) != 0 {
+				problems = append(problems, "map has unexpected "+keyList(extra, displayActualString))
+			}
+			if len(problems) != 0 {
+				return cty.UnknownVal(retType), function.NewArgErrorf(0, "%s", strings.Join(problems, "; "))
+			}
+			return v, nil
+		}
+
+		// We'll use the length refinement to detect whether the unknown
+		// value is already known to have a length that contradicts our
+		// assumption.
+		if _, ok := tryApplyRefinement(v, func(b *cty.RefinementBuilder) *cty.RefinementBuilder {
+			return b.NotNull().CollectionLength(len(keys))
+		}); !ok {
+			err := explainCollectionLength(v, "map", len(keys), len(keys))
+			if err == nil {
+				err = errAssumptionNotUpheld
+			}
+			return cty.UnknownVal(retType), function.NewArgError(0, err)
+		}
+		if len(keys) == 0 {
+			return cty.MapValEmpty(retType.ElementType()), nil
+		}
+		elems := make(map[string]cty.Value, len(keys))
+		for k := range keys {
+			elems[k] = cty.UnknownVal(retType.ElementType())
+		}
+		return cty.MapVal(elems), nil
+	},
+}
+
+var listofFunc = makeRefineFunc(
+	cty.List(cty.DynamicPseudoType),
+	"Assume that the given list will have exactly the given number of elements.",
+	func(args []cty.Value) error {
+		if v, acc := args[0].AsBigFloat().Int64(); acc != big.Exact || v < 0 || v >= math.MaxInt {
+			return function.NewArgErrorf(0, "must be a whole number between 0 and %d", math.MaxInt)
+		}
+		return nil
+	},
+	func(args []cty.Value, b *cty.RefinementBuilder) *cty.RefinementBuilder {
+		// Our argument validator above already guaranteed that the
+		// argument is a whole number that can fit into an int.
+		length, _ := args[0].AsBigFloat().Int64()
+		// cty automatically converts an unknown list with an exact length
+		// into a known list of unknown elements, as long as it's also
+		// known not to be null.
+		return b.NotNull().CollectionLength(int(length))
+	},
+	func(v cty.Value, args []cty.Value) error {
+		if err := explainNotNull(v); err != nil {
+			return err
+		}
+		length, _ := args[0].AsBigFloat().Int64()
+		return explainCollectionLength(v, "list", int(length), int(length))
+	},
+	function.Parameter{
+		Name:        "length",
+		Type:        cty.Number,
+		Description: "The number of elements to assume.",
+	},
+)
+
+var tupleofFunc = &function.Spec{
+	Description: "Assume that the given tuple will have exactly the given number of elements.",
+	Params: []function.Parameter{
+		{
+			Name:             "value",
+			Type:             cty.DynamicPseudoType,
+			Description:      "The value to make the assumption about.",
+			AllowNull:        true,
+			AllowUnknown:     true,
+			AllowDynamicType: true,
+		},
+		{
+			Name:        "length",
+			Type:        cty.Number,
+			Description: "The number of elements to assume.",
+		},
+	},
+	Type: func(args []cty.Value) (cty.Type, error) {
+		ty := args[0].Type()
+		if !ty.IsTupleType() && ty != cty.DynamicPseudoType {
+			return cty.DynamicPseudoType, function.NewArgErrorf(0, "must be a tuple")
+		}
+		if !args[1].IsKnown() {
+			return ty, nil
+		}
+		length, acc := args[1].AsBigFloat().Int64()
+		if acc != big.Exact || length < 0 || length >= math.MaxInt {
+			return cty.DynamicPseudoType, function.NewArgErrorf(1, "must be a whole number between 0 and %d", math.MaxInt)
+		}
+		if ty == cty.DynamicPseudoType {
+			// We don't know anything about the element types, but we can
+			// at least know how many elements there are.
+			etys := make([]cty.Type, length)
+			for i := range etys {
+				etys[i] = cty.DynamicPseudoType
+			}
+			return cty.Tuple(etys), nil
+		}
+		if have := len(ty.TupleElementTypes()); have != int(length) {
+			return cty.DynamicPseudoType, function.NewArgErrorf(0, "tuple has %s but was assumed to have exactly %d", elementCount(have), length)
+		}
+		return ty, nil
+	},
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		// Our type function above already checked that the tuple type has
+		// the assumed number of elements, so only nullness remains.
+		v := args[0]
+		if !v.IsKnown() {
+			if _, ok := tryApplyRefinement(v, (*cty.RefinementBuilder).NotNull); !ok {
+				return cty.UnknownVal(retType), function.NewArgError(0, errAssumptionNotUpheld)
+			}
+			etys := retType.TupleElementTypes()
+			elems := make([]cty.Value, len(etys))
+			for i, ety := range etys {
+				elems[i] = cty.UnknownVal(ety)
+			}
+			if len(elems) == 0 {
+				return cty.EmptyTupleVal, nil
+			}
+			return cty.TupleVal(elems), nil
+		}
+		if v.IsNull() {
+			return cty.UnknownVal(retType), function.NewArgError(0, explainNotNull(v))
+		}
+		return v, nil
+	},
+}
+
+// assumedMapKeys interprets the "keys" argument of mapkeys, returning the
+// set of keys that it describes.
+//
+// The given value is always known, because the parameter doesn't allow
+// unknown values, but if it's a collection with any unknown elements then
+// the set of keys can't be decided yet and so the result is nil with no
+// error.
+func assumedMapKeys(v cty.Value) (map[string]struct{}, error) {
+	ty := v.Type()
+	switch {
+	case ty.IsMapType() || ty.IsObjectType():
+		ret := make(map[string]struct{}, v.LengthInt())
+		for it := v.ElementIterator(); it.Next(); {
+			k, _ := it.Element()
+			ret[k.AsString()] = struct{}{}
+		}
+		return ret, nil
+	case ty.IsListType() || ty.IsSetType() || ty.IsTupleType():
+		if !v.IsWhollyKnown() {
+			return nil, nil
+		}
+		ret := make(map[string]struct{}, v.LengthInt())
+		for it := v.ElementIterator(); it.Next(); {
+			_, k := it.Element()
+			k, err := convert.Convert(k, cty.String)
+			if err != nil {
+				return nil, fmt.Errorf("all keys must be strings")
+			}
+			if k.IsNull() {
+				return nil, fmt.Errorf("keys must not be null")
+			}
+			ret[k.AsString()] = struct{}{}
+		}
+		return ret, nil
+	default:
+		return nil, fmt.Errorf("must be a collection of keys, or a map or object to take keys from")
+	}
+}
+
+// keyList returns a phrase listing the given keys in a deterministic order,
+// such as `keys "a" and "b"`, using the given function to display each key.
+//
+// Keys taken from the actual value should be displayed with
+// displayActualString, so that they respect the redaction mode.
+func keyList(keys []string, display func(string) string) string {
+	sort.Strings(keys)
+	quoted := make([]string, len(keys))
+	for i, k := range keys {
+		quoted[i] = display(k)
+	}
+	switch len(quoted) {
+	case 1:
+		return "key " + quoted[0]
+	default:
+		return "keys " + strings.Join(quoted[:len(quoted)-1], ", ") + " and " + quoted[len(quoted)-1]
+	}
+}
@@ -0,0 +1,260 @@
+package assume
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestShapeFuncs(t *testing.T) {
+	testProviderFuncs(t, funcTests{
+		"mapkeys": {
+			"dynamicval": {
+				Args: []cty.Value{
+					cty.DynamicVal,
+					cty.ListVal([]cty.Value{cty.StringVal("a")}),
+				},
+				Want: cty.DynamicVal,
+			},
+			"unknown map with key list": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.Map(cty.String)),
+					cty.ListVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b"), cty.StringVal("a")}),
+				},
+				Want: cty.MapVal(map[string]cty.Value{
+					"a": cty.UnknownVal(cty.String),
+					"b": cty.UnknownVal(cty.String),
+				}),
+			},
+			"unknown map with key tuple": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.Map(cty.Number)),
+					cty.TupleVal([]cty.Value{cty.StringVal("a")}),
+				},
+				Want: cty.MapVal(map[string]cty.Value{
+					"a": cty.UnknownVal(cty.Number),
+				}),
+			},
+			"unknown map with reference object": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.Map(cty.String)),
+					cty.ObjectVal(map[string]cty.Value{
+						"a": cty.UnknownVal(cty.Bool),
+						"b": cty.True,
+					}),
+				},
+				Want: cty.MapVal(map[string]cty.Value{
+					"a": cty.UnknownVal(cty.String),
+					"b": cty.UnknownVal(cty.String),
+				}),
+			},
+			"unknown map with no keys": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.Map(cty.String)),
+					cty.ListValEmpty(cty.String),
+				},
+				Want: cty.MapValEmpty(cty.String),
+			},
+			"unknown map with partially-unknown key list": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.Map(cty.String)),
+					cty.ListVal([]cty.Value{cty.StringVal("a"), cty.UnknownVal(cty.String)}),
+				},
+				Want: cty.UnknownVal(cty.Map(cty.String)),
+			},
+			"unknown key list": {
+				// The function isn't called at all for unknown keys, so the
+				// result is a wholly-unknown value.
+				Args: []cty.Value{
+					cty.MapVal(map[string]cty.Value{"a": cty.StringVal("x")}),
+					cty.UnknownVal(cty.List(cty.String)),
+				},
+				Want: cty.UnknownVal(cty.Map(cty.String)),
+			},
+			"unknown map with conflicting length": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.Map(cty.String)).Refine().
+						CollectionLengthUpperBound(1).
+						NewValue(),
+					cty.ListVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b")}),
+				},
+				WantErr: "map already known to have at most 1 element which conflicts with assumed minimum length 2",
+			},
+			"known map with correct keys": {
+				Args: []cty.Value{
+					cty.MapVal(map[string]cty.Value{
+						"a": cty.StringVal("A"),
+						"b": cty.UnknownVal(cty.String),
+					}),
+					cty.SetVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b")}),
+				},
+				Want: cty.MapVal(map[string]cty.Value{
+					"a": cty.StringVal("A"),
+					"b": cty.UnknownVal(cty.String),
+				}),
+			},
+			"known map with incorrect keys": {
+				Args: []cty.Value{
+					cty.MapVal(map[string]cty.Value{
+						"a": cty.StringVal("A"),
+						"d": cty.StringVal("D"),
+					}),
+					cty.ListVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b"), cty.StringVal("c")}),
+				},
+				WantErr: `map is missing keys "b" and "c"; map has unexpected key "d"`,
+			},
+			"null map": {
+				Args: []cty.Value{
+					cty.NullVal(cty.Map(cty.String)),
+					cty.ListVal([]cty.Value{cty.StringVal("a")}),
+				},
+				WantErr: `value must not be null`,
+			},
+			"invalid keys": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.Map(cty.String)),
+					cty.StringVal("a"),
+				},
+				WantErr: `must be a collection of keys, or a map or object to take keys from`,
+			},
+			"non-string keys": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.Map(cty.String)),
+					cty.TupleVal([]cty.Value{cty.ListValEmpty(cty.String)}),
+				},
+				WantErr: `all keys must be strings`,
+			},
+		},
+		"listof": {
+			"dynamicval": {
+				Args: []cty.Value{
+					cty.DynamicVal,
+					cty.NumberIntVal(2),
+				},
+				Want: cty.DynamicVal,
+			},
+			"unknown list": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.List(cty.String)),
+					cty.NumberIntVal(2),
+				},
+				Want: cty.ListVal([]cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.UnknownVal(cty.String),
+				}),
+			},
+			"unknown list with no elements": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.List(cty.String)),
+					cty.Zero,
+				},
+				Want: cty.ListValEmpty(cty.String),
+			},
+			"known list with correct length": {
+				Args: []cty.Value{
+					cty.ListVal([]cty.Value{cty.StringVal("a")}),
+					cty.NumberIntVal(1),
+				},
+				Want: cty.ListVal([]cty.Value{cty.StringVal("a")}),
+			},
+			"known list with incorrect length": {
+				Args: []cty.Value{
+					cty.ListVal([]cty.Value{cty.StringVal("a")}),
+					cty.NumberIntVal(2),
+				},
+				WantErr: "list has 1 element but was assumed to have exactly 2",
+			},
+			"null list": {
+				Args: []cty.Value{
+					cty.NullVal(cty.List(cty.String)),
+					cty.NumberIntVal(2),
+				},
+				WantErr: "value is null but was assumed to never be null",
+			},
+			"negative length": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.List(cty.String)),
+					cty.NumberIntVal(-1),
+				},
+				WantErr: fmt.Sprintf("must be a whole number between 0 and %d", math.MaxInt),
+			},
+		},
+		"tupleof": {
+			"dynamicval": {
+				Args: []cty.Value{
+					cty.DynamicVal,
+					cty.NumberIntVal(2),
+				},
+				Want: cty.TupleVal([]cty.Value{cty.DynamicVal, cty.DynamicVal}),
+			},
+			"unknown tuple": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.Tuple([]cty.Type{cty.String, cty.Number})),
+					cty.NumberIntVal(2),
+				},
+				Want: cty.TupleVal([]cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.UnknownVal(cty.Number),
+				}),
+			},
+			"unknown empty tuple": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.EmptyTuple),
+					cty.Zero,
+				},
+				Want: cty.EmptyTupleVal,
+			},
+			"unknown tuple with incorrect length": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.Tuple([]cty.Type{cty.String})),
+					cty.NumberIntVal(2),
+				},
+				WantErr: "tuple has 1 element but was assumed to have exactly 2",
+			},
+			"known tuple with correct length": {
+				Args: []cty.Value{
+					cty.TupleVal([]cty.Value{cty.StringVal("a"), cty.True}),
+					cty.NumberIntVal(2),
+				},
+				Want: cty.TupleVal([]cty.Value{cty.StringVal("a"), cty.True}),
+			},
+			"known tuple with incorrect length": {
+				Args: []cty.Value{
+					cty.TupleVal([]cty.Value{cty.True, cty.True}),
+					cty.NumberIntVal(3),
+				},
+				WantErr: "tuple has 2 elements but was assumed to have exactly 3",
+			},
+			"known tuple with fractional length": {
+				Args: []cty.Value{
+					cty.TupleVal([]cty.Value{cty.True, cty.True}),
+					cty.NumberFloatVal(2.5),
+				},
+				WantErr: "must be a whole number between 0 and 9223372036854775807",
+			},
+			"dynamicval with fractional length": {
+				Args: []cty.Value{
+					cty.DynamicVal,
+					cty.NumberFloatVal(2.5),
+				},
+				WantErr: "must be a whole number between 0 and 9223372036854775807",
+			},
+			"null tuple": {
+				Args: []cty.Value{
+					cty.NullVal(cty.Tuple([]cty.Type{cty.String})),
+					cty.NumberIntVal(1),
+				},
+				WantErr: "value is null but was assumed to never be null",
+			},
+			"not a tuple": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.List(cty.String)),
+					cty.NumberIntVal(1),
+				},
+				WantErr: `must be a tuple`,
+			},
+		},
+	})
+}
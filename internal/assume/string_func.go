@@ -0,0 +1,348 @@
+package assume
+
+import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+var stringmatchFunc = &function.Spec{
+	Description: "Assume that the given string will match the given regular expression.",
+	Params: []function.Parameter{
+		{
+			Name:         "value",
+			Type:         cty.String,
+			Description:  "The value to make the assumption about.",
+			AllowNull:    true,
+			AllowUnknown: true,
+		},
+		{
+			Name:        "pattern",
+			Type:        cty.String,
+			Description: "A regular expression using the same syntax as Terraform's regex function.",
+		},
+	},
+	Type: function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		v := args[0]
+		pattern := args[1].AsString()
+		re, err := compileAssumedPattern(pattern)
+		if err != nil {
+			return cty.UnknownVal(retType), function.NewArgError(1, err)
+		}
+
+		if v.IsKnown() {
+			if v.IsNull() {
+				// As with stringprefix, a null string is consistent with
+				// any pattern. Use notnull to also assume it's not null.
+				return v, nil
+			}
+			if !re.MatchString(v.AsString()) {
+				return cty.UnknownVal(retType), function.NewArgErrorf(0, "value %s does not match the assumed pattern %q", displayActualValue(v), pattern)
+			}
+			return v, nil
+		}
+
+		// While the value is unknown we can only describe the literal
+		// prefix that all matching strings must have, if any.
+		prefix := regexpLiteralPrefix(re)
+		if prefix == "" {
+			return v, nil
+		}
+		return refineStringPrefix(v, prefix, false, fmt.Sprintf("implied by the assumed pattern %q", pattern))
+	},
+}
+
+var stringtemplateFunc = &function.Spec{
+	Description: "Assume that the given string will follow the given template, whose placeholders may refer to values that are not yet known.",
+	Params: []function.Parameter{
+		{
+			Name:         "value",
+			Type:         cty.String,
+			Description:  "The value to make the assumption about.",
+			AllowNull:    true,
+			AllowUnknown: true,
+		},
+		{
+			Name:        "template",
+			Type:        cty.String,
+			Description: "A template string using ${name} placeholders to refer to the given variables, and $${ to represent a literal ${.",
+		},
+		{
+			Name:             "vars",
+			Type:             cty.DynamicPseudoType,
+			Description:      "An object or map whose attributes or elements are the values for the template placeholders.",
+			AllowUnknown:     true,
+			AllowDynamicType: true,
+		},
+	},
+	Type: function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		v := args[0]
+		tmpl := args[1].AsString()
+		parts, err := parseStringTemplate(tmpl)
+		if err != nil {
+			return cty.UnknownVal(retType), function.NewArgError(1, err)
+		}
+		segs, err := renderStringTemplate(parts, args[2])
+		if err != nil {
+			return cty.UnknownVal(retType), function.NewArgError(2, err)
+		}
+
+		if len(segs) == 1 && segs[0].literal {
+			// All of the variables are known, so the template describes
+			// exactly one string.
+			rendered := cty.StringVal(segs[0].text)
+			if v.IsKnown() && v.IsNull() {
+				return cty.UnknownVal(retType), function.NewArgErrorf(0, "value is null but was assumed to follow the template %q", tmpl)
+			}
+			if eq := v.Equals(rendered); eq.IsKnown() && eq.False() {
+				return cty.UnknownVal(retType), function.NewArgErrorf(0, "value %s does not match %s, rendered from the assumed template %q", displayActualValue(v), displayActualString(segs[0].text), tmpl)
+			}
+			return rendered, nil
+		}
+
+		if v.IsKnown() {
+			if v.IsNull() {
+				return cty.UnknownVal(retType), function.NewArgErrorf(0, "value is null but was assumed to follow the template %q", tmpl)
+			}
+			if !stringTemplatePattern(segs).MatchString(v.AsString()) {
+				return cty.UnknownVal(retType), function.NewArgErrorf(0, "value %s does not follow the assumed template %q", displayActualValue(v), tmpl)
+			}
+			return v, nil
+		}
+
+		// While the value is unknown we can describe its known prefix and
+		// that it is not null, since no template renders as null.
+		var prefix string
+		if segs[0].literal {
+			prefix = segs[0].text
+		}
+		return refineStringPrefix(v, prefix, true, fmt.Sprintf("implied by the assumed template %q", tmpl))
+	},
+}
+
+// stringTemplatePart is either a literal part of a template string or a
+// placeholder referring to a variable.
+type stringTemplatePart struct {
+	literal string
+	varName string // set only for placeholders
+}
+
+// parseStringTemplate parses a template string as accepted by the
+// stringtemplate function, returning the literal parts and placeholders
+// in the order they appear.
+func parseStringTemplate(tmpl string) ([]stringTemplatePart, error) {
+	var parts []stringTemplatePart
+	var lit strings.Builder
+	for len(tmpl) > 0 {
+		switch {
+		case strings.HasPrefix(tmpl, "$${"):
+			lit.WriteString("${")
+			tmpl = tmpl[3:]
+		case strings.HasPrefix(tmpl, "${"):
+			end := strings.IndexByte(tmpl, '}')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed template placeholder; use $${ to represent a literal ${")
+			}
+			name := strings.TrimSpace(tmpl[2:end])
+			if name == "" {
+				return nil, fmt.Errorf("template placeholder must contain a variable name")
+			}
+			if lit.Len() != 0 {
+				parts = append(parts, stringTemplatePart{literal: lit.String()})
+				lit.Reset()
+			}
+			parts = append(parts, stringTemplatePart{varName: name})
+			tmpl = tmpl[end+1:]
+		default:
+			lit.WriteByte(tmpl[0])
+			tmpl = tmpl[1:]
+		}
+	}
+	if lit.Len() != 0 {
+		parts = append(parts, stringTemplatePart{literal: lit.String()})
+	}
+	return parts, nil
+}
+
+// stringTemplateSegment is a part of a rendered template, which is either
+// literal text or a placeholder whose variable is not yet known.
+type stringTemplateSegment struct {
+	literal bool
+	text    string
+}
+
+// renderStringTemplate substitutes the known variables from the given object
+// or map into the given template parts.
+//
+// The result has consecutive literal text merged into single segments, so
+// if all of the variables are known then the result is a single literal
+// segment. (A template with no parts at all also renders as a single empty
+// literal segment.)
+func renderStringTemplate(parts []stringTemplatePart, vars cty.Value) ([]stringTemplateSegment, error) {
+	varsTy := vars.Type()
+	if !(varsTy.IsObjectType() || varsTy.IsMapType() || varsTy == cty.DynamicPseudoType) {
+		return nil, fmt.Errorf("must be an object or map of template variables")
+	}
+	if vars.IsKnown() && vars.IsNull() {
+		return nil, fmt.Errorf("must not be null")
+	}
+
+	var segs []stringTemplateSegment
+	addLiteral := func(s string) {
+		if n := len(segs); n != 0 && segs[n-1].literal {
+			segs[n-1].text += s
+			return
+		}
+		segs = append(segs, stringTemplateSegment{literal: true, text: s})
+	}
+	for _, part := range parts {
+		if part.varName == "" {
+			addLiteral(part.literal)
+			continue
+		}
+		val, err := stringTemplateVar(vars, part.varName)
+		if err != nil {
+			return nil, err
+		}
+		if !val.IsKnown() {
+			segs = append(segs, stringTemplateSegment{})
+			continue
+		}
+		addLiteral(val.AsString())
+	}
+	if len(segs) == 0 {
+		addLiteral("")
+	}
+	return segs, nil
+}
+
+// stringTemplateVar returns the value of the variable of the given name as
+// a string, which may be unknown.
+func stringTemplateVar(vars cty.Value, name string) (cty.Value, error) {
+	if !vars.IsKnown() {
+		// We don't know the variables yet, and so we can't even check
+		// whether this one is defined.
+		return cty.UnknownVal(cty.String), nil
+	}
+	ty := vars.Type()
+	var val cty.Value
+	switch {
+	case ty.IsObjectType():
+		if !ty.HasAttribute(name) {
+			return cty.NilVal, fmt.Errorf("no variable named %q", name)
+		}
+		val = vars.GetAttr(name)
+	default:
+		key := cty.StringVal(name)
+		if !vars.HasIndex(key).True() {
+			return cty.NilVal, fmt.Errorf("no variable named %q", name)
+		}
+		val = vars.Index(key)
+	}
+	val, err := convert.Convert(val, cty.String)
+	if err != nil {
+		return cty.NilVal, fmt.Errorf("invalid value for variable %q: %w", name, err)
+	}
+	if val.IsKnown() && val.IsNull() {
+		return cty.NilVal, fmt.Errorf("variable %q must not be null", name)
+	}
+	return val, nil
+}
+
+// stringTemplatePattern returns a regular expression that matches any string
+// that could be produced by the given template segments.
+//
+// Each unknown placeholder matches any text that doesn't include the first
+// character of the literal text that follows it, which is typically the
+// delimiter between the parts of a structured string. A placeholder at the
+// end of the template matches any text.
+func stringTemplatePattern(segs []stringTemplateSegment) *regexp.Regexp {
+	var buf strings.Builder
+	buf.WriteString(`(?s)\A`)
+	for i, seg := range segs {
+		if seg.literal {
+			buf.WriteString(regexp.QuoteMeta(seg.text))
+			continue
+		}
+		delim := ""
+		for _, next := range segs[i+1:] {
+			if next.literal && next.text != "" {
+				r, _ := utf8.DecodeRuneInString(next.text)
+				delim = string(r)
+				break
+			}
+		}
+		if delim == "" {
+			buf.WriteString(`.*`)
+		} else {
+			buf.WriteString(`[^` + regexp.QuoteMeta(delim) + `]*`)
+		}
+	}
+	buf.WriteString(`\z`)
+	return regexp.MustCompile(buf.String())
+}
+
+// compileAssumedPattern compiles the given regular expression pattern,
+// returning an error suitable for use in an argument error if it is invalid.
+func compileAssumedPattern(pattern string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		if syntaxErr, ok := err.(*syntax.Error); ok {
+			return nil, fmt.Errorf("invalid regular expression: %s: %s", syntaxErr.Code, syntaxErr.Expr)
+		}
+		return nil, fmt.Errorf("invalid regular expression: %w", err)
+	}
+	return re, nil
+}
+
+// regexpLiteralPrefix returns the literal string that every string matching
+// the given regular expression must start with, or an empty string if
+// there is no such prefix.
+//
+// Only patterns anchored to the start of the string using ^ or \A can have
+// a prefix, because otherwise the match could begin anywhere in the string.
+func regexpLiteralPrefix(re *regexp.Regexp) string {
+	parsed, err := syntax.Parse(re.String(), syntax.Perl)
+	if err != nil {
+		// Should not happen, since the pattern already compiled.
+		return ""
+	}
+	seq := flattenRegexpConcat(parsed.Simplify())
+	if len(seq) == 0 || seq[0].Op != syntax.OpBeginText {
+		return ""
+	}
+	var buf strings.Builder
+	for _, sub := range seq[1:] {
+		if sub.Op != syntax.OpLiteral || sub.Flags&syntax.FoldCase != 0 {
+			break
+		}
+		buf.WriteString(string(sub.Rune))
+	}
+	return buf.String()
+}
+
+// flattenRegexpConcat returns the sequence of expressions that must match
+// one after another for the given expression to match, flattening any
+// nested concatenations and capture groups.
+func flattenRegexpConcat(re *syntax.Regexp) []*syntax.Regexp {
+	switch re.Op {
+	case syntax.OpConcat:
+		var ret []*syntax.Regexp
+		for _, sub := range re.Sub {
+			ret = append(ret, flattenRegexpConcat(sub)...)
+		}
+		return ret
+	case syntax.OpCapture:
+		return flattenRegexpConcat(re.Sub[0])
+	default:
+		return []*syntax.Regexp{re}
+	}
+}
@@ -0,0 +1,300 @@
+package assume
+
+import (
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestStringFuncs(t *testing.T) {
+	testProviderFuncs(t, funcTests{
+		"stringmatch": {
+			"dynamicval": {
+				Args: []cty.Value{
+					cty.DynamicVal,
+					cty.StringVal(`^arn:`),
+				},
+				Want: cty.DynamicVal,
+			},
+			"unknown string with anchored pattern": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.StringVal(`^arn:aws:iam::[0-9]{12}:role/.+$`),
+				},
+				Want: cty.UnknownVal(cty.String).Refine().
+					StringPrefixFull("arn:aws:iam::").
+					NewValue(),
+			},
+			"unknown string with anchored pattern in group": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.StringVal(`\A(vpc-)([0-9a-f]+)$`),
+				},
+				Want: cty.UnknownVal(cty.String).Refine().
+					StringPrefixFull("vpc-").
+					NewValue(),
+			},
+			"unknown string with unanchored pattern": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.StringVal(`arn:aws:`),
+				},
+				Want: cty.UnknownVal(cty.String),
+			},
+			"unknown string with case-insensitive pattern": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.StringVal(`(?i)^arn:`),
+				},
+				Want: cty.UnknownVal(cty.String),
+			},
+			"unknown string with multi-line pattern": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.StringVal(`(?m)^arn:`),
+				},
+				Want: cty.UnknownVal(cty.String),
+			},
+			"unknown string with alternation": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.StringVal(`^(arn:aws:|arn:aws-cn:)`),
+				},
+				// The common prefix of the alternatives is factored out
+				// during parsing, but its last character could combine with
+				// a subsequent diacritic and so is not included.
+				Want: cty.UnknownVal(cty.String).Refine().
+					StringPrefixFull("arn:aw").
+					NewValue(),
+			},
+			"unknown string with conflicting prefix": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String).Refine().StringPrefixFull("arn:aws-cn:").NewValue(),
+					cty.StringVal(`^arn:aws:iam::`),
+				},
+				WantErr: `value already known to start with "arn:aws-cn:" which conflicts with assumed prefix "arn:aws:iam::", implied by the assumed pattern "^arn:aws:iam::"`,
+			},
+			"null string": {
+				Args: []cty.Value{
+					cty.NullVal(cty.String),
+					cty.StringVal(`^arn:`),
+				},
+				Want: cty.NullVal(cty.String),
+			},
+			"known string that matches": {
+				Args: []cty.Value{
+					cty.StringVal("arn:aws:iam::123456789012:role/example"),
+					cty.StringVal(`^arn:aws:iam::[0-9]{12}:role/.+$`),
+				},
+				Want: cty.StringVal("arn:aws:iam::123456789012:role/example"),
+			},
+			"known string that doesn't match": {
+				Args: []cty.Value{
+					cty.StringVal("arn:aws:iam::12345:role/example"),
+					cty.StringVal(`^arn:aws:iam::[0-9]{12}:role/.+$`),
+				},
+				WantErr: `value "arn:aws:iam::12345:role/example" does not match the assumed pattern "^arn:aws:iam::[0-9]{12}:role/.+$"`,
+			},
+			"invalid pattern": {
+				Args: []cty.Value{
+					cty.StringVal("a"),
+					cty.StringVal(`^[a`),
+				},
+				WantErr: "invalid regular expression: missing closing ]: [a",
+			},
+		},
+		"stringtemplate": {
+			"all variables known": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.StringVal("arn:${partition}:iam::${account}:role/${name}"),
+					cty.ObjectVal(map[string]cty.Value{
+						"partition": cty.StringVal("aws"),
+						"account":   cty.StringVal("123456789012"),
+						"name":      cty.StringVal("example"),
+					}),
+				},
+				Want: cty.StringVal("arn:aws:iam::123456789012:role/example"),
+			},
+			"all variables known from map": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.StringVal("${a}-$${b}"),
+					cty.MapVal(map[string]cty.Value{
+						"a": cty.StringVal("x"),
+					}),
+				},
+				Want: cty.StringVal("x-${b}"),
+			},
+			"number variable": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.StringVal("port-${port}"),
+					cty.ObjectVal(map[string]cty.Value{
+						"port": cty.NumberIntVal(443),
+					}),
+				},
+				Want: cty.StringVal("port-443"),
+			},
+			"some variables unknown": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.StringVal("arn:${partition}:iam::${account}:role/${name}"),
+					cty.ObjectVal(map[string]cty.Value{
+						"partition": cty.StringVal("aws"),
+						"account":   cty.UnknownVal(cty.String),
+						"name":      cty.StringVal("example"),
+					}),
+				},
+				Want: cty.UnknownVal(cty.String).Refine().
+					NotNull().
+					StringPrefixFull("arn:aws:iam::").
+					NewValue(),
+			},
+			"first variable unknown": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.StringVal("${name}.example.com"),
+					cty.ObjectVal(map[string]cty.Value{
+						"name": cty.UnknownVal(cty.String),
+					}),
+				},
+				Want: cty.UnknownVal(cty.String).RefineNotNull(),
+			},
+			"variables wholly unknown": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.StringVal("arn:${partition}:iam::${account}:role/${name}"),
+					cty.UnknownVal(cty.Map(cty.String)),
+				},
+				Want: cty.UnknownVal(cty.String).Refine().
+					NotNull().
+					StringPrefixFull("arn:").
+					NewValue(),
+			},
+			"unknown string with conflicting prefix": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String).Refine().StringPrefixFull("arn:aws-cn:").NewValue(),
+					cty.StringVal("arn:aws:iam::${account}:role/example"),
+					cty.ObjectVal(map[string]cty.Value{
+						"account": cty.UnknownVal(cty.String),
+					}),
+				},
+				WantErr: `value already known to start with "arn:aws-cn:" which conflicts with assumed prefix "arn:aws:iam::", implied by the assumed template "arn:aws:iam::${account}:role/example"`,
+			},
+			"unknown string with conflicting prefix and all variables known": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String).Refine().StringPrefixFull("arn:aws-cn:").NewValue(),
+					cty.StringVal("arn:${partition}:"),
+					cty.ObjectVal(map[string]cty.Value{
+						"partition": cty.StringVal("aws"),
+					}),
+				},
+				WantErr: `value (a string starting with "arn:aws-cn:") does not match "arn:aws:", rendered from the assumed template "arn:${partition}:"`,
+			},
+			"known string that matches rendered template": {
+				Args: []cty.Value{
+					cty.StringVal("arn:aws:iam::123456789012:role/example"),
+					cty.StringVal("arn:${partition}:iam::${account}:role/${name}"),
+					cty.ObjectVal(map[string]cty.Value{
+						"partition": cty.StringVal("aws"),
+						"account":   cty.StringVal("123456789012"),
+						"name":      cty.StringVal("example"),
+					}),
+				},
+				Want: cty.StringVal("arn:aws:iam::123456789012:role/example"),
+			},
+			"known string that doesn't match rendered template": {
+				Args: []cty.Value{
+					cty.StringVal("arn:aws:iam::123456789012:role/other"),
+					cty.StringVal("arn:aws:iam::${account}:role/${name}"),
+					cty.ObjectVal(map[string]cty.Value{
+						"account": cty.StringVal("123456789012"),
+						"name":    cty.StringVal("example"),
+					}),
+				},
+				WantErr: `value "arn:aws:iam::123456789012:role/other" does not match "arn:aws:iam::123456789012:role/example", rendered from the assumed template "arn:aws:iam::${account}:role/${name}"`,
+			},
+			"known string that follows template": {
+				Args: []cty.Value{
+					cty.StringVal("arn:aws:iam::123456789012:role/path/example"),
+					cty.StringVal("arn:${partition}:iam::${account}:role/${name}"),
+					cty.ObjectVal(map[string]cty.Value{
+						"partition": cty.StringVal("aws"),
+						"account":   cty.UnknownVal(cty.String),
+						"name":      cty.UnknownVal(cty.String),
+					}),
+				},
+				// The final placeholder can match any text, including
+				// further slashes.
+				Want: cty.StringVal("arn:aws:iam::123456789012:role/path/example"),
+			},
+			"known string with delimiter in unknown placeholder": {
+				Args: []cty.Value{
+					cty.StringVal("arn:aws:iam::1234:5678:role/example"),
+					cty.StringVal("arn:aws:iam::${account}:role/${name}"),
+					cty.ObjectVal(map[string]cty.Value{
+						"account": cty.UnknownVal(cty.String),
+						"name":    cty.StringVal("example"),
+					}),
+				},
+				WantErr: `value "arn:aws:iam::1234:5678:role/example" does not follow the assumed template "arn:aws:iam::${account}:role/${name}"`,
+			},
+			"known string that doesn't follow template": {
+				Args: []cty.Value{
+					cty.StringVal("arn:aws:s3:::example"),
+					cty.StringVal("arn:aws:iam::${account}:role/${name}"),
+					cty.ObjectVal(map[string]cty.Value{
+						"account": cty.UnknownVal(cty.String),
+						"name":    cty.UnknownVal(cty.String),
+					}),
+				},
+				WantErr: `value "arn:aws:s3:::example" does not follow the assumed template "arn:aws:iam::${account}:role/${name}"`,
+			},
+			"null string": {
+				Args: []cty.Value{
+					cty.NullVal(cty.String),
+					cty.StringVal("arn:${partition}:"),
+					cty.ObjectVal(map[string]cty.Value{
+						"partition": cty.UnknownVal(cty.String),
+					}),
+				},
+				WantErr: `value is null but was assumed to follow the template "arn:${partition}:"`,
+			},
+			"undefined variable": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.StringVal("arn:${partition}:"),
+					cty.EmptyObjectVal,
+				},
+				WantErr: `no variable named "partition"`,
+			},
+			"null variable": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.StringVal("arn:${partition}:"),
+					cty.ObjectVal(map[string]cty.Value{
+						"partition": cty.NullVal(cty.String),
+					}),
+				},
+				WantErr: `variable "partition" must not be null`,
+			},
+			"unclosed placeholder": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.StringVal("arn:${partition"),
+					cty.EmptyObjectVal,
+				},
+				WantErr: "unclosed template placeholder; use $${ to represent a literal ${",
+			},
+			"variables not an object": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.StringVal("arn:"),
+					cty.StringVal("aws"),
+				},
+				WantErr: "must be an object or map of template variables",
+			},
+		},
+	})
+}
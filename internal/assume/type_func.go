@@ -0,0 +1,92 @@
+package assume
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+var typedFunc = &function.Spec{
+	Description: "Assume that the given value will conform to the given type constraint.",
+	Params: []function.Parameter{
+		{
+			Name:             "value",
+			Type:             cty.DynamicPseudoType,
+			Description:      "The value to make the assumption about.",
+			AllowNull:        true,
+			AllowUnknown:     true,
+			AllowDynamicType: true,
+		},
+		{
+			Name:        "type",
+			Type:        cty.String,
+			Description: "A type constraint using the same syntax as Terraform's input variable type constraints.",
+		},
+	},
+	Type: func(args []cty.Value) (cty.Type, error) {
+		if !args[1].IsKnown() {
+			// We can't know the result type until we know the constraint.
+			return cty.DynamicPseudoType, nil
+		}
+		ty, _, err := parseTypeConstraint(args[1].AsString())
+		if err != nil {
+			return cty.DynamicPseudoType, function.NewArgError(1, err)
+		}
+		if ty.HasDynamicTypes() {
+			// The final type will depend on the value, so we can't decide
+			// it until we've converted the value.
+			return cty.DynamicPseudoType, nil
+		}
+		return ty.WithoutOptionalAttributesDeep(), nil
+	},
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		ty, defaults, err := parseTypeConstraint(args[1].AsString())
+		if err != nil {
+			return cty.UnknownVal(retType), function.NewArgError(1, err)
+		}
+		v := args[0]
+		if defaults != nil {
+			v = defaults.Apply(v)
+		}
+		ret, err := convert.Convert(v, ty)
+		if err != nil {
+			return cty.UnknownVal(retType), function.NewArgErrorf(0, "value does not conform to type %s: %s", typeexpr.TypeString(ty), err)
+		}
+		return ret, nil
+	},
+}
+
+// parseTypeConstraint parses the given string as a type constraint
+// expression, using the same syntax as Terraform's input variable type
+// constraints including the optional attribute modifier.
+func parseTypeConstraint(src string) (cty.Type, *typeexpr.Defaults, error) {
+	expr, diags := hclsyntax.ParseExpression([]byte(src), "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return cty.DynamicPseudoType, nil, fmt.Errorf("invalid type constraint syntax: %s", diagsSummary(diags))
+	}
+	ty, defaults, diags := typeexpr.TypeConstraintWithDefaults(expr)
+	if diags.HasErrors() {
+		return cty.DynamicPseudoType, nil, fmt.Errorf("invalid type constraint: %s", diagsSummary(diags))
+	}
+	return ty, defaults, nil
+}
+
+// diagsSummary returns the detail of the first error in the given
+// diagnostics, which is the most relevant part to include in a function
+// error message.
+func diagsSummary(diags hcl.Diagnostics) string {
+	for _, diag := range diags {
+		if diag.Severity == hcl.DiagError {
+			if diag.Detail != "" {
+				return diag.Detail
+			}
+			return diag.Summary
+		}
+	}
+	return "unknown error"
+}
@@ -0,0 +1,121 @@
+package assume
+
+import (
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestTypeFuncs(t *testing.T) {
+	testProviderFuncs(t, funcTests{
+		"typed": {
+			"dynamicval": {
+				Args: []cty.Value{
+					cty.DynamicVal,
+					cty.StringVal("list(object({id=string}))"),
+				},
+				Want: cty.UnknownVal(cty.List(cty.Object(map[string]cty.Type{
+					"id": cty.String,
+				}))),
+			},
+			"dynamicval with optional attribute": {
+				Args: []cty.Value{
+					cty.DynamicVal,
+					cty.StringVal("object({id=string, name=optional(string, \"x\")})"),
+				},
+				Want: cty.UnknownVal(cty.Object(map[string]cty.Type{
+					"id":   cty.String,
+					"name": cty.String,
+				})),
+			},
+			"unknown type constraint": {
+				Args: []cty.Value{
+					cty.StringVal("a"),
+					cty.UnknownVal(cty.String),
+				},
+				Want: cty.DynamicVal,
+			},
+			"unknown value of convertible type": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.Number),
+					cty.StringVal("string"),
+				},
+				Want: cty.UnknownVal(cty.String),
+			},
+			"refined unknown value": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String).Refine().StringPrefix("a-").NewValue(),
+					cty.StringVal("string"),
+				},
+				Want: cty.UnknownVal(cty.String).Refine().StringPrefix("a-").NewValue(),
+			},
+			"known value that conforms": {
+				Args: []cty.Value{
+					cty.TupleVal([]cty.Value{
+						cty.ObjectVal(map[string]cty.Value{
+							"id":    cty.StringVal("a"),
+							"extra": cty.True,
+						}),
+					}),
+					cty.StringVal("list(object({id=string}))"),
+				},
+				Want: cty.ListVal([]cty.Value{
+					cty.ObjectVal(map[string]cty.Value{
+						"id": cty.StringVal("a"),
+					}),
+				}),
+			},
+			"known value with defaults": {
+				Args: []cty.Value{
+					cty.ObjectVal(map[string]cty.Value{
+						"id": cty.StringVal("a"),
+					}),
+					cty.StringVal("object({id=string, name=optional(string, \"x\")})"),
+				},
+				Want: cty.ObjectVal(map[string]cty.Value{
+					"id":   cty.StringVal("a"),
+					"name": cty.StringVal("x"),
+				}),
+			},
+			"known value with any type": {
+				Args: []cty.Value{
+					cty.TupleVal([]cty.Value{cty.StringVal("a"), cty.True}),
+					cty.StringVal("list(any)"),
+				},
+				Want: cty.ListVal([]cty.Value{cty.StringVal("a"), cty.StringVal("true")}),
+			},
+			"null value": {
+				Args: []cty.Value{
+					cty.NullVal(cty.DynamicPseudoType),
+					cty.StringVal("map(string)"),
+				},
+				Want: cty.NullVal(cty.Map(cty.String)),
+			},
+			"known value that doesn't conform": {
+				Args: []cty.Value{
+					cty.TupleVal([]cty.Value{
+						cty.ObjectVal(map[string]cty.Value{
+							"name": cty.StringVal("a"),
+						}),
+					}),
+					cty.StringVal("list(object({id=string}))"),
+				},
+				WantErr: `value does not conform to type list(object({id=string})): element 0: attribute "id" is required`,
+			},
+			"invalid type syntax": {
+				Args: []cty.Value{
+					cty.DynamicVal,
+					cty.StringVal("list("),
+				},
+				WantErr: `invalid type constraint syntax: Expected the start of an expression, but found the end of the file.`,
+			},
+			"invalid type constraint": {
+				Args: []cty.Value{
+					cty.DynamicVal,
+					cty.StringVal("lisst(string)"),
+				},
+				WantErr: `invalid type constraint: Keyword "lisst" is not a valid type constructor.`,
+			},
+		},
+	})
+}
@@ -0,0 +1,193 @@
+package assume
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+// maxValueDiffs is the maximum number of differences that valueDiff will
+// report before summarizing the rest, so that a mismatch between two large
+// values doesn't produce an overwhelming error message.
+const maxValueDiffs = 10
+
+// maxDiffStringLen is the maximum number of characters of a string that
+// valueDiff will include in a difference before eliding the middle of it.
+const maxDiffStringLen = 40
+
+// valueDiff returns a compact description of the differences between an
+// actual value and the value it was assumed to equal, with one line per
+// difference.
+//
+// The two values are usually of the same type, but objects with different
+// attributes and tuples with different lengths are also compared in detail.
+// Any parts of either value that are not yet known are ignored, because they
+// might yet turn out to match.
+func valueDiff(actual, assumed cty.Value) []string {
+	var diffs []string
+	collectValueDiffs(actual, assumed, nil, &diffs)
+	if len(diffs) > maxValueDiffs {
+		more := len(diffs) - maxValueDiffs
+		diffs = diffs[:maxValueDiffs]
+		if more == 1 {
+			diffs = append(diffs, "...and 1 more difference")
+		} else {
+			diffs = append(diffs, fmt.Sprintf("...and %d more differences", more))
+		}
+	}
+	return diffs
+}
+
+func collectValueDiffs(actual, assumed cty.Value, path cty.Path, diffs *[]string) {
+	if eq := actual.Equals(assumed); eq.IsKnown() && eq.True() {
+		return
+	}
+	if !actual.IsKnown() || !assumed.IsKnown() {
+		// A wholly-unknown value might yet turn out to match. (If it
+		// couldn't possibly match due to its refinements then the caller
+		// is responsible for reporting that.)
+		return
+	}
+	where := diffPathString(path)
+	ty := actual.Type()
+	bothObjects := ty.IsObjectType() && assumed.Type().IsObjectType()
+	bothTuples := ty.IsTupleType() && assumed.Type().IsTupleType()
+	if !ty.Equals(assumed.Type()) && !bothObjects && !bothTuples {
+		*diffs = append(*diffs, fmt.Sprintf("%s: %s vs %s", where, ty.FriendlyName(), assumed.Type().FriendlyName()))
+		return
+	}
+	if actual.IsNull() || assumed.IsNull() || ty.IsPrimitiveType() {
+		*diffs = append(*diffs, fmt.Sprintf("%s: %s vs %s", where, diffDisplayValue(actual), diffDisplayValue(assumed)))
+		return
+	}
+
+	switch {
+	case ty.IsObjectType():
+		assumedTy := assumed.Type()
+		names := make([]string, 0, len(ty.AttributeTypes()))
+		for name := range ty.AttributeTypes() {
+			names = append(names, name)
+		}
+		for name := range assumedTy.AttributeTypes() {
+			if !ty.HasAttribute(name) {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			switch {
+			case !assumedTy.HasAttribute(name):
+				*diffs = append(*diffs, fmt.Sprintf("%s: unexpected attribute %s", where, displayActualString(name)))
+			case !ty.HasAttribute(name):
+				*diffs = append(*diffs, fmt.Sprintf("%s: missing attribute %q", where, name))
+			default:
+				collectValueDiffs(actual.GetAttr(name), assumed.GetAttr(name), path.Copy().GetAttr(name), diffs)
+			}
+		}
+	case ty.IsMapType():
+		actualElems := actual.AsValueMap()
+		assumedElems := assumed.AsValueMap()
+		keys := make([]string, 0, len(actualElems)+len(assumedElems))
+		for k := range actualElems {
+			keys = append(keys, k)
+		}
+		for k := range assumedElems {
+			if _, exists := actualElems[k]; !exists {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			av, inActual := actualElems[k]
+			ev, inAssumed := assumedElems[k]
+			switch {
+			case !inAssumed:
+				*diffs = append(*diffs, fmt.Sprintf("%s: unexpected key %s", where, displayActualString(k)))
+			case !inActual:
+				*diffs = append(*diffs, fmt.Sprintf("%s: missing key %q", where, k))
+			default:
+				collectValueDiffs(av, ev, path.Copy().IndexString(k), diffs)
+			}
+		}
+	case ty.IsListType() || ty.IsTupleType():
+		actualElems := actual.AsValueSlice()
+		assumedElems := assumed.AsValueSlice()
+		if len(actualElems) != len(assumedElems) {
+			*diffs = append(*diffs, fmt.Sprintf("%s: has %s but assumed value has %d", where, elementCount(len(actualElems)), len(assumedElems)))
+		}
+		for i := 0; i < len(actualElems) && i < len(assumedElems); i++ {
+			collectValueDiffs(actualElems[i], assumedElems[i], path.Copy().IndexInt(i), diffs)
+		}
+	case ty.IsSetType():
+		// Set elements have no identity other than their values, so we can
+		// only report which elements appear in one set but not the other,
+		// and only once both sets are wholly known.
+		if !actual.IsWhollyKnown() || !assumed.IsWhollyKnown() {
+			return
+		}
+		for it := actual.ElementIterator(); it.Next(); {
+			_, elem := it.Element()
+			if !assumed.HasElement(elem).True() {
+				*diffs = append(*diffs, fmt.Sprintf("%s: unexpected element %s", where, diffDisplayValue(elem)))
+			}
+		}
+		for it := assumed.ElementIterator(); it.Next(); {
+			_, elem := it.Element()
+			if !actual.HasElement(elem).True() {
+				*diffs = append(*diffs, fmt.Sprintf("%s: missing element %s", where, diffDisplayValue(elem)))
+			}
+		}
+	}
+}
+
+// diffPathString returns the string representation of the given path for
+// use in a value difference, which is just "value" for the top-level value.
+func diffPathString(path cty.Path) string {
+	if len(path) == 0 {
+		return "value"
+	}
+	return formatPath(path)
+}
+
+// diffDisplayValue returns a compact representation of the given value for
+// use in a value difference, summarizing any values that are too large or
+// too complex to show in full.
+func diffDisplayValue(v cty.Value) string {
+	ty := v.Type()
+	switch {
+	case v.IsNull():
+		return "null"
+	case !v.IsKnown():
+		return "(not yet known)"
+	case ty.IsPrimitiveType() && redaction != redactNone:
+		return displayActualValue(v)
+	case ty == cty.String:
+		s := v.AsString()
+		if utf8.RuneCountInString(s) <= maxDiffStringLen {
+			return strconv.Quote(s)
+		}
+		runes := []rune(s)
+		keep := maxDiffStringLen / 2
+		return strconv.Quote(string(runes[:keep]) + "..." + string(runes[len(runes)-keep:]))
+	case ty.IsPrimitiveType():
+		return simpleDisplayValue(v)
+	case ty.IsObjectType():
+		if len(ty.AttributeTypes()) == 0 {
+			return "empty object"
+		}
+		names := make([]string, 0, len(ty.AttributeTypes()))
+		for name := range ty.AttributeTypes() {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return "object with attributes " + strings.Join(names, ", ")
+	case ty.IsListType() || ty.IsSetType() || ty.IsMapType() || ty.IsTupleType():
+		return fmt.Sprintf("%s with %s", collectionNoun(ty), elementCount(v.LengthInt()))
+	default:
+		return ty.FriendlyName()
+	}
+}
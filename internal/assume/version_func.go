@@ -0,0 +1,344 @@
+package assume
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+var semverFunc = &function.Spec{
+	Description: "Assume that the given string will be a version number that satisfies the given version constraint.",
+	Params: []function.Parameter{
+		{
+			Name:         "value",
+			Type:         cty.String,
+			Description:  "The value to make the assumption about.",
+			AllowNull:    true,
+			AllowUnknown: true,
+		},
+		{
+			Name:        "constraint",
+			Type:        cty.String,
+			Description: "A version constraint using the same syntax as Terraform's required_version argument, like \">= 1.28, < 1.31\".",
+		},
+	},
+	Type: function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		v := args[0]
+		src := args[1].AsString()
+		constraints, err := parseVersionConstraints(src)
+		if err != nil {
+			return cty.UnknownVal(retType), function.NewArgError(1, err)
+		}
+		prefix := versionConstraintsPrefix(constraints)
+
+		if v.IsKnown() {
+			if v.IsNull() {
+				return cty.UnknownVal(retType), function.NewArgErrorf(0, "value is null but was assumed to be a version number")
+			}
+			s := v.AsString()
+			version, ok := parseVersion(s)
+			if !ok {
+				return cty.UnknownVal(retType), function.NewArgErrorf(0, "value %s is not a version number", displayActualValue(v))
+			}
+			for _, c := range constraints {
+				if !c.check(version) {
+					return cty.UnknownVal(retType), function.NewArgErrorf(0, "value %s does not satisfy %q in the assumed constraint %q", displayActualValue(v), c.String(), src)
+				}
+			}
+			if vPrefix, consistent := constraintsVPrefix(constraints); consistent && version.hasV != (vPrefix != "") {
+				if version.hasV {
+					return cty.UnknownVal(retType), function.NewArgErrorf(0, "value %s has a \"v\" prefix, but the assumed constraint %q is written without one", displayActualValue(v), src)
+				}
+				return cty.UnknownVal(retType), function.NewArgErrorf(0, "value %s has no \"v\" prefix, but the assumed constraint %q is written with one", displayActualValue(v), src)
+			}
+			return v, nil
+		}
+
+		return refineStringPrefix(v, prefix, true, fmt.Sprintf("implied by the assumed constraint %q", src))
+	},
+}
+
+// versionPattern matches a version number, which may be partial and may
+// have a "v" prefix, a prerelease suffix, and build metadata.
+var versionPattern = regexp.MustCompile(`^(v)?([0-9]+)(?:\.([0-9]+))?(?:\.([0-9]+))?(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`)
+
+// version is a parsed version number.
+type version struct {
+	// segments has between one and three elements, depending on how many
+	// of the major, minor, and patch numbers were given. Missing segments
+	// are treated as zero when comparing versions.
+	segments []uint64
+	pre      string
+	hasV     bool
+}
+
+// parseVersion parses a version number in the form
+// MAJOR[.MINOR[.PATCH]][-PRERELEASE][+BUILD], with an optional "v" prefix,
+// or returns false if the given string isn't a version number.
+func parseVersion(s string) (version, bool) {
+	m := versionPattern.FindStringSubmatch(s)
+	if m == nil {
+		return version{}, false
+	}
+	ret := version{
+		pre:  m[5],
+		hasV: m[1] != "",
+	}
+	for _, seg := range m[2:5] {
+		if seg == "" {
+			break
+		}
+		n, err := strconv.ParseUint(seg, 10, 64)
+		if err != nil {
+			return version{}, false
+		}
+		ret.segments = append(ret.segments, n)
+	}
+	return ret, true
+}
+
+// segment returns the major, minor, or patch number given its index,
+// which is zero if it wasn't specified.
+func (v version) segment(i int) uint64 {
+	if i < len(v.segments) {
+		return v.segments[i]
+	}
+	return 0
+}
+
+// compare returns a negative number if v is lower than other, a positive
+// number if v is higher, or zero if they are equal, using the precedence
+// rules from Semantic Versioning.
+func (v version) compare(other version) int {
+	for i := 0; i < 3; i++ {
+		a, b := v.segment(i), other.segment(i)
+		if a != b {
+			if a < b {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case v.pre == other.pre:
+		return 0
+	case v.pre == "":
+		return 1
+	case other.pre == "":
+		return -1
+	}
+	as, bs := strings.Split(v.pre, "."), strings.Split(other.pre, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if c := comparePrereleaseIdent(as[i], bs[i]); c != 0 {
+			return c
+		}
+	}
+	return len(as) - len(bs)
+}
+
+// comparePrereleaseIdent compares two dot-separated parts of a prerelease
+// suffix, where numeric parts are compared numerically and are lower than
+// any non-numeric parts.
+func comparePrereleaseIdent(a, b string) int {
+	an, aErr := strconv.ParseUint(a, 10, 64)
+	bn, bErr := strconv.ParseUint(b, 10, 64)
+	switch {
+	case aErr == nil && bErr == nil:
+		switch {
+		case an < bn:
+			return -1
+		case an > bn:
+			return 1
+		}
+		return 0
+	case aErr == nil:
+		return -1
+	case bErr == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// versionConstraint is a single comparison from a version constraint
+// string, such as ">= 1.28".
+type versionConstraint struct {
+	op      string
+	version version
+	raw     string
+}
+
+// parseVersionConstraints parses a comma-separated version constraint
+// string using the same syntax as Terraform's required_version argument.
+func parseVersionConstraints(src string) ([]versionConstraint, error) {
+	var ret []versionConstraint
+	for _, part := range strings.Split(src, ",") {
+		part = strings.TrimSpace(part)
+		op := "="
+		for _, candidate := range []string{"~>", ">=", "<=", "!=", ">", "<", "="} {
+			if rest, ok := strings.CutPrefix(part, candidate); ok {
+				op, part = candidate, strings.TrimSpace(rest)
+				break
+			}
+		}
+		v, ok := parseVersion(part)
+		if !ok {
+			return nil, fmt.Errorf("invalid version constraint: %q is not a version number", part)
+		}
+		ret = append(ret, versionConstraint{op: op, version: v, raw: part})
+	}
+	return ret, nil
+}
+
+// String returns the constraint in the same form as it would be written in
+// a constraint string.
+func (c versionConstraint) String() string {
+	return c.op + " " + c.raw
+}
+
+// check returns true if the given version satisfies the constraint.
+//
+// As with Terraform's own version constraints, a version with a prerelease
+// suffix only satisfies a constraint that has a prerelease suffix for the
+// same major, minor, and patch numbers.
+func (c versionConstraint) check(v version) bool {
+	if v.pre != "" {
+		if c.version.pre == "" {
+			return false
+		}
+		for i := 0; i < 3; i++ {
+			if v.segment(i) != c.version.segment(i) {
+				return false
+			}
+		}
+	}
+	cmp := v.compare(c.version)
+	switch c.op {
+	case "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case "~>":
+		if cmp < 0 {
+			return false
+		}
+		// Only the rightmost given segment may increase, except that a
+		// constraint with only a major version allows the minor version
+		// to increase too.
+		fixed := max(len(c.version.segments)-1, 1)
+		for i := 0; i < fixed; i++ {
+			if v.segment(i) != c.version.segment(i) {
+				return false
+			}
+		}
+		return true
+	default:
+		panic(fmt.Sprintf("unsupported constraint operator %q", c.op))
+	}
+}
+
+// versionBound is a lower or upper bound of a range of versions.
+type versionBound struct {
+	version   version
+	inclusive bool
+}
+
+// versionConstraintsPrefix returns the longest prefix that all version
+// strings satisfying the given constraints must start with.
+//
+// The prefix starts with "v" if the constraints are written with one, as
+// described in constraintsVPrefix, and there is no prefix at all if the
+// constraints are inconsistent about it. The prefix then includes the major
+// version if the constraints allow only one, followed by a dot only if they
+// rule out a partial version that is only a major version.
+func versionConstraintsPrefix(constraints []versionConstraint) string {
+	var lower, upper *versionBound
+	vPrefix, consistent := constraintsVPrefix(constraints)
+	if !consistent {
+		return ""
+	}
+	for _, c := range constraints {
+		var lo, hi *versionBound
+		switch c.op {
+		case "=":
+			lo = &versionBound{c.version, true}
+			hi = lo
+		case ">", ">=":
+			lo = &versionBound{c.version, c.op == ">="}
+		case "<", "<=":
+			hi = &versionBound{c.version, c.op == "<="}
+		case "~>":
+			lo = &versionBound{c.version, true}
+			next := version{segments: []uint64{c.version.segment(0) + 1, 0, 0}}
+			if len(c.version.segments) == 3 {
+				next.segments = []uint64{c.version.segment(0), c.version.segment(1) + 1, 0}
+			}
+			hi = &versionBound{next, false}
+		}
+		if lo != nil && (lower == nil || lo.version.compare(lower.version) > 0) {
+			lower = lo
+		}
+		if hi != nil && (upper == nil || hi.version.compare(upper.version) < 0 || (hi.version.compare(upper.version) == 0 && !hi.inclusive)) {
+			upper = hi
+		}
+	}
+	if upper == nil {
+		return vPrefix
+	}
+	if lower == nil {
+		lower = &versionBound{version{segments: []uint64{0}}, true}
+	}
+
+	major := lower.version.segment(0)
+	if upper.version.segment(0) != major {
+		// An exclusive upper bound at the very start of the next major
+		// version also allows only one major version, as long as it has
+		// no prerelease suffix that would sort before it.
+		next := version{segments: []uint64{major + 1}}
+		if upper.inclusive || upper.version.compare(next) != 0 {
+			return vPrefix
+		}
+	}
+	prefix := vPrefix + strconv.FormatUint(major, 10)
+	bare := version{segments: []uint64{major}}
+	for _, c := range constraints {
+		if !c.check(bare) {
+			return prefix + "."
+		}
+	}
+	return prefix
+}
+
+// constraintsVPrefix returns "v" if all of the versions in the given
+// constraints are written with a "v" prefix, or an empty string if none of
+// them are, in which case the version being checked must be written the same
+// way. If the constraints are inconsistent about it then the second result
+// is false and a "v" prefix is optional.
+func constraintsVPrefix(constraints []versionConstraint) (string, bool) {
+	withV := 0
+	for _, c := range constraints {
+		if c.version.hasV {
+			withV++
+		}
+	}
+	switch withV {
+	case len(constraints):
+		return "v", true
+	case 0:
+		return "", true
+	default:
+		return "", false
+	}
+}
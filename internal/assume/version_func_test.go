@@ -0,0 +1,224 @@
+package assume
+
+import (
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestVersionFuncs(t *testing.T) {
+	testProviderFuncs(t, funcTests{
+		"semver": {
+			"single major version": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.StringVal(">= 1.28, < 1.31"),
+				},
+				Want: cty.UnknownVal(cty.String).Refine().
+					NotNull().
+					StringPrefixFull("1.").
+					NewValue(),
+			},
+			"single major version with v prefix": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.StringVal(">= v1.28, < v1.31"),
+				},
+				Want: cty.UnknownVal(cty.String).Refine().
+					NotNull().
+					StringPrefixFull("v1.").
+					NewValue(),
+			},
+			"single major version with v prefix allowing bare major": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.StringVal("~> v15.0"),
+				},
+				// "v15" would satisfy the constraint, so there might not be
+				// a dot after the major version.
+				Want: cty.UnknownVal(cty.String).Refine().
+					NotNull().
+					StringPrefix("v15").
+					NewValue(),
+			},
+			"several major versions with v prefix": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.StringVal(">= v1.2.0, < v3.0.0"),
+				},
+				Want: cty.UnknownVal(cty.String).Refine().
+					NotNull().
+					StringPrefix("v").
+					NewValue(),
+			},
+			"single major version allowing bare major": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.StringVal("~> 15.0"),
+				},
+				Want: cty.UnknownVal(cty.String).Refine().
+					NotNull().
+					StringPrefix("15").
+					NewValue(),
+			},
+			"mixed v prefixes": {
+				// The constraint doesn't say whether the value has a "v"
+				// prefix, so it's optional and there's no prefix.
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.StringVal(">= v1.2.0, < v2.0.0, != 1.5.0"),
+				},
+				Want: cty.UnknownVal(cty.String).Refine().
+					NotNull().
+					NewValue(),
+			},
+			"upper bound before prerelease": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.StringVal(">= v1.0.0, < v2.0.0-beta"),
+				},
+				Want: cty.UnknownVal(cty.String).Refine().
+					NotNull().
+					StringPrefix("v").
+					NewValue(),
+			},
+			"several major versions": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.StringVal(">= 13, <= 16"),
+				},
+				Want: cty.UnknownVal(cty.String).Refine().
+					NotNull().
+					NewValue(),
+			},
+			"no upper bound": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.StringVal(">= 1.28"),
+				},
+				Want: cty.UnknownVal(cty.String).Refine().
+					NotNull().
+					NewValue(),
+			},
+			"unknown string with conflicting prefix": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String).Refine().StringPrefixFull("v2.").NewValue(),
+					cty.StringVal("~> v1.28"),
+				},
+				WantErr: `value already known to start with "v2." which conflicts with assumed prefix "v1.", implied by the assumed constraint "~> v1.28"`,
+			},
+			"known version that satisfies": {
+				Args: []cty.Value{
+					cty.StringVal("1.29.3"),
+					cty.StringVal(">= 1.28, < 1.31"),
+				},
+				Want: cty.StringVal("1.29.3"),
+			},
+			"known partial version that satisfies": {
+				Args: []cty.Value{
+					cty.StringVal("15.4"),
+					cty.StringVal("~> 15.4.0"),
+				},
+				Want: cty.StringVal("15.4"),
+			},
+			"known version with v prefix and mixed constraint": {
+				Args: []cty.Value{
+					cty.StringVal("v1.29"),
+					cty.StringVal(">= 1.28, != v1.30"),
+				},
+				Want: cty.StringVal("v1.29"),
+			},
+			"known version above upper bound": {
+				Args: []cty.Value{
+					cty.StringVal("1.31.2"),
+					cty.StringVal(">= 1.28, < 1.31"),
+				},
+				WantErr: `value "1.31.2" does not satisfy "< 1.31" in the assumed constraint ">= 1.28, < 1.31"`,
+			},
+			"known version below lower bound": {
+				Args: []cty.Value{
+					cty.StringVal("1.27"),
+					cty.StringVal(">= 1.28, < 1.31"),
+				},
+				WantErr: `value "1.27" does not satisfy ">= 1.28" in the assumed constraint ">= 1.28, < 1.31"`,
+			},
+			"known version outside pessimistic constraint": {
+				Args: []cty.Value{
+					cty.StringVal("15.5.1"),
+					cty.StringVal("~> 15.4.0"),
+				},
+				WantErr: `value "15.5.1" does not satisfy "~> 15.4.0" in the assumed constraint "~> 15.4.0"`,
+			},
+			"known prerelease version": {
+				Args: []cty.Value{
+					cty.StringVal("1.29.0-rc.1"),
+					cty.StringVal(">= 1.28"),
+				},
+				WantErr: `value "1.29.0-rc.1" does not satisfy ">= 1.28" in the assumed constraint ">= 1.28"`,
+			},
+			"known prerelease version with prerelease constraint": {
+				Args: []cty.Value{
+					cty.StringVal("1.29.0-rc.10"),
+					cty.StringVal(">= 1.29.0-rc.2"),
+				},
+				Want: cty.StringVal("1.29.0-rc.10"),
+			},
+			"known version with unexpected v prefix": {
+				Args: []cty.Value{
+					cty.StringVal("v1.29"),
+					cty.StringVal(">= 1.28, < 1.31"),
+				},
+				WantErr: `value "v1.29" has a "v" prefix, but the assumed constraint ">= 1.28, < 1.31" is written without one`,
+			},
+			"known version with required v prefix": {
+				Args: []cty.Value{
+					cty.StringVal("v1.29"),
+					cty.StringVal(">= v1.28, < v1.31"),
+				},
+				Want: cty.StringVal("v1.29"),
+			},
+			"known version without required v prefix": {
+				Args: []cty.Value{
+					cty.StringVal("1.29"),
+					cty.StringVal(">= v1.28, < v1.31"),
+				},
+				WantErr: `value "1.29" has no "v" prefix, but the assumed constraint ">= v1.28, < v1.31" is written with one`,
+			},
+			"known major version only": {
+				Args: []cty.Value{
+					cty.StringVal("15"),
+					cty.StringVal("= 15"),
+				},
+				Want: cty.StringVal("15"),
+			},
+			"known major version only with pessimistic constraint": {
+				Args: []cty.Value{
+					cty.StringVal("15"),
+					cty.StringVal("~> 15.0"),
+				},
+				Want: cty.StringVal("15"),
+			},
+			"known string that isn't a version": {
+				Args: []cty.Value{
+					cty.StringVal("5.7.mysql_aurora.2.11.2"),
+					cty.StringVal(">= 5.7"),
+				},
+				WantErr: `value "5.7.mysql_aurora.2.11.2" is not a version number`,
+			},
+			"null string": {
+				Args: []cty.Value{
+					cty.NullVal(cty.String),
+					cty.StringVal(">= 1.28"),
+				},
+				WantErr: "value is null but was assumed to be a version number",
+			},
+			"invalid constraint": {
+				Args: []cty.Value{
+					cty.UnknownVal(cty.String),
+					cty.StringVal(">= 1.28, latest"),
+				},
+				WantErr: `invalid version constraint: "latest" is not a version number`,
+			},
+		},
+	})
+}